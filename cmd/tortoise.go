@@ -13,6 +13,7 @@ import (
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"time"
 
 	"github.com/rocky/go-loader"
 	"github.com/rocky/ssa-interp"
@@ -45,11 +46,24 @@ R	disable [R]ecover() from panic; show interpreter crash instead.
 T	[T]race execution of the program.  Best for single-threaded programs!
 I	trace [I]int() functions before main.main()
 S	[S]atement tracing
+D	[D]eterministic scheduling of goroutines; see -seed.
 `)
 
+var seedFlag = flag.Int64("seed", 0,
+	`Seed for the goroutine scheduling choices of -interp=D. With 0, a seed
+is picked and shown, so that the run can be repeated.`)
+
 var gubFlag = flag.String("gub", "", `Options passed to the gub debugger.
 `)
 
+var leakCheckFlag = flag.Bool("leakcheck", false,
+	`Report goroutines still running when main.main returns, and fail
+with exit code 3 if there are any. tortoise exits with the exit code
+of the program.`)
+
+var postMortemFlag = flag.Bool("postmortem", false,
+	`Enter the gub debugger at the panicking frame if the program dies from a panic.`)
+
 const usage = `SSA builder and interpreter.
 Usage: tortoise [<flag> ...] [<file.go> ...] [<arg> ...]
        tortoise [<flag> ...] <import/path>   [<arg> ...]
@@ -60,6 +74,9 @@ Examples:
 % tortoise -build=FPG hello.go            # quickly dump SSA form of a single package
 % tortoise -run -interp=T hello.go        # interpret a program, with tracing
 % tortoise -run -test unicode -- -test.v  # interpret the unicode package's tests, verbosely
+% tortoise -run -postmortem hello.go      # debug hello.go if it dies from a panic
+% tortoise -run -interp=D -seed=42 x.go   # interpret with goroutines scheduled from seed 42
+% tortoise -run -leakcheck x.go           # fail if x.go leaves goroutines running
 ` + loader.FromArgsUsage +
 	`
 When -run is specified, tortoise will run the program.
@@ -148,11 +165,31 @@ func doMain() error {
 			mode |= ssa2.GlobalDebug
 		case 'T':
 			interpTraceMode |= interp.EnableTracing
+		case 'D':
+			interpMode |= interp.Deterministic
 		default:
 			return fmt.Errorf("unknown -interp option: '%c'", c)
 		}
 	}
 
+	if interpMode&interp.Deterministic != 0 {
+		seed := *seedFlag
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+			fmt.Printf("Scheduling goroutines with -seed=%d\n", seed)
+		}
+		interp.SetScheduleSeed(seed)
+	}
+
+	if *leakCheckFlag {
+		interpMode |= interp.EnableLeakCheck
+	}
+
+	if *postMortemFlag {
+		interpMode |= interp.EnablePostMortem
+		mode |= ssa2.GlobalDebug
+	}
+
 	if len(args) == 0 {
 		fmt.Fprint(os.Stderr, usage)
 		os.Exit(1)
@@ -240,6 +277,8 @@ func doMain() error {
 		} else if prog.PackagesByPath["github.com/rocky/ssa-interp/trepan"] != nil {
 			fmt.Println("I see you've got trepan imported...")
 			gubcmd.Init(gubFlag, restart_args, main.Prog)
+		} else if *postMortemFlag {
+			gubcmd.Init(gubFlag, restart_args, main.Prog)
 		}
 
 		fmt.Println("Running....")
@@ -248,7 +287,10 @@ func doMain() error {
 				build.Default.GOARCH, runtime.GOARCH)
 		}
 
-		interp.Interpret(main, interpMode, interpTraceMode, conf.TypeChecker.Sizes, main.Object.Path(), prog_args)
+		exitCode := interp.Interpret(main, interpMode, interpTraceMode, conf.TypeChecker.Sizes, main.Object.Path(), prog_args)
+		if *leakCheckFlag && exitCode != 0 {
+			os.Exit(exitCode)
+		}
 	}  else {
 		fmt.Println(`Built ok, but not running because "-run" option not given`)
 	}
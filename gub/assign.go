@@ -0,0 +1,136 @@
+// Copyright 2015 Rocky Bernstein.
+// Assigning to variables, fields and elements of the program.
+
+package gub
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// SplitAssign splits "lhs = rhs" at the assignment's "=", skipping
+// any inside quotes or brackets and the "=" of ==, !=, <= and >=.
+func SplitAssign(s string) (string, string, error) {
+	depth := 0
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote && (i == 0 || s[i-1] != '\\') { quote = 0 }
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == '=' && depth == 0:
+			if i+1 < len(s) && s[i+1] == '=' { continue }
+			if i > 0 && strings.ContainsRune("=!<>", rune(s[i-1])) { continue }
+			lhs := strings.TrimSpace(s[:i])
+			rhs := strings.TrimSpace(s[i+1:])
+			if lhs == "" || rhs == "" { break }
+			return lhs, rhs, nil
+		}
+	}
+	return "", "", errors.New("expecting lvalue = expression")
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uintptr
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// convertForAssign converts rv, computed by eval, to a value of
+// basic type typ the way Go assignment would, rejecting values of
+// another kind and integers that don't fit.
+func convertForAssign(rv reflect.Value, typ types.Type) (interp.Value, error) {
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return nil, fmt.Errorf("can only assign a variable of the same type to %s", typ)
+	}
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("expression has no value")
+	}
+	k := rv.Kind()
+	info := basic.Info()
+	ok = kindFits(k, info)
+	if ok && info&types.IsInteger != 0 && isFloatKind(k) {
+		ok = rv.Float() == math.Trunc(rv.Float())
+	}
+	if !ok {
+		return nil, fmt.Errorf("type mismatch: can't assign a %s value to %s", rv.Type(), typ)
+	}
+	v, err := Reflect2InterpVal(rv, typ)
+	if err != nil { return nil, err }
+	if info&types.IsInteger != 0 {
+		negative := k >= reflect.Int && k <= reflect.Int64 && rv.Int() < 0 ||
+			isFloatKind(k) && rv.Float() < 0
+		back := reflect.ValueOf(v).Convert(rv.Type()).Interface()
+		if back != rv.Interface() || negative && info&types.IsUnsigned != 0 {
+			return nil, fmt.Errorf("%v overflows %s", rv.Interface(), typ)
+		}
+	}
+	return v, nil
+}
+
+// assignValue computes rhs in frame fr as a value for a location of
+// type typ. rhs may be nil, another location of the same type, a call
+// of a function of the program, or an expression of basic type.
+func assignValue(fr *interp.Frame, rhs string, typ types.Type) (interp.Value, error) {
+	if rhs == "nil" {
+		switch typ.Underlying().(type) {
+		case *types.Pointer, *types.Slice, *types.Map, *types.Chan,
+			*types.Signature, *types.Interface:
+			return interp.Zero(typ), nil
+		}
+		return nil, fmt.Errorf("can't use nil as a value of type %s", typ)
+	}
+	if expr, err := parser.ParseExpr(rhs); err == nil {
+		if call, ok := expr.(*ast.CallExpr); ok {
+			v, err := callValue(fr, call, typ)
+			if err != errNotCallee { return v, err }
+		}
+		if lv, err := resolveLvalue(fr, expr); err == nil {
+			if !types.Identical(lv.typ, typ) {
+				return nil, fmt.Errorf("type mismatch: can't assign %s to %s",
+					lv.typ, typ)
+			}
+			v, ok := lv.read()
+			if !ok { return nil, fmt.Errorf("%s has no value", rhs) }
+			return interp.CopyVal(v), nil
+		}
+	}
+	results, err := EvalExprInFrame(fr, rhs)
+	if err != nil { return nil, err }
+	if len(results) != 1 {
+		return nil, fmt.Errorf("%s should have a single value", rhs)
+	}
+	return convertForAssign(results[0], typ)
+}
+
+// AssignInFrame sets the location given by lhs in frame fr to the
+// value of rhs. It returns the new value, formatted for showing.
+func AssignInFrame(fr *interp.Frame, lhs string, rhs string) (string, error) {
+	if Replaying() {
+		return "", errors.New("can't change the program while going over recorded history")
+	}
+	expr, err := parser.ParseExpr(lhs)
+	if err != nil { return "", err }
+	lv, err := resolveLvalue(fr, expr)
+	if err != nil { return "", err }
+	v, err := assignValue(fr, rhs, lv.typ)
+	if err != nil { return "", err }
+	if err := lv.write(v); err != nil { return "", err }
+	return watchFormat(v, lv.typ), nil
+}
@@ -3,11 +3,13 @@ package gub
 import (
 	"go/token"
 	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
 	"fmt"
+	"strconv"
 )
 
 type Breakpoint struct {
-	// condition
+	Condition string    // Go expression that must be true to stop. "" if none
 	Hits    int       // How many times hit (with a true condition)
 	Id      int      // Id of breakpoint. Is position inside of Breakpoints
 	Deleted bool      // Set when breakpoint is deleted
@@ -17,7 +19,16 @@ type Breakpoint struct {
 	EndP    token.Pos // End Position of breakpoint
 	Ignore  int       // Number of times to ignore before triggering
 	Kind    string    // 'Function' if function breakpoint. 'Stmt'
-	                  // if at a statement boundary
+	                  // if at a statement boundary. 'Watchpoint'
+	                  // if a watchpoint. 'Catchpoint' if a catchpoint
+	Watch   *WatchInfo // Set when Kind is 'Watchpoint'
+	Commands []string  // Debugger commands run when the breakpoint stops
+	Log     string    // Logpoint format; set for a logpoint, which never
+	                  // stops
+	Fn      *ssa2.Function // Set when Kind is 'Function'
+	Trace   *ssa2.Trace // Set when Kind is 'Statement'
+	Catch   ssa2.TraceEvent // Event stopped at when Kind is 'Catchpoint'
+	GoNum   int       // Goroutine the breakpoint stops in; -1 for any
 }
 
 var Breakpoints []*Breakpoint
@@ -41,6 +52,28 @@ func BreakpointAdd(bp *Breakpoint) int {
 	return len(Breakpoints)-1
 }
 
+// WatchpointAdd adds watchpoint or catchpoint bp. These are numbered
+// along with breakpoints, but since they have no position they don't
+// go into BrkptLocs.
+func WatchpointAdd(bp *Breakpoint) int {
+	Breakpoints = append(Breakpoints, bp)
+	return len(Breakpoints)-1
+}
+
+// GetBpnum converts arg to a breakpoint number. "$bpnum" stands for
+// the most recently set breakpoint, so that command files can refer
+// to breakpoints they have just set.
+func GetBpnum(arg string, what string) (int, error) {
+	if arg == "$bpnum" {
+		if len(Breakpoints) == 0 {
+			Errmsg("No breakpoints.")
+			return 0, genericError
+		}
+		return len(Breakpoints)-1, nil
+	}
+	return GetInt(arg, what, 0, len(Breakpoints)-1)
+}
+
 func BreakpointExists(bpnum int) bool {
 	if bpnum < len(Breakpoints) {
 		return !Breakpoints[bpnum].Deleted
@@ -48,9 +81,26 @@ func BreakpointExists(bpnum int) bool {
 	return false
 }
 
+// BreakpointDelete deletes breakpoint bpnum. If that leaves no
+// breakpoint at its statement, the interpreter no longer stops there.
 func BreakpointDelete(bpnum int) bool {
 	if BreakpointExists(bpnum) {
-		Breakpoints[bpnum].Deleted = true
+		bp := Breakpoints[bpnum]
+		bp.Deleted = true
+		BrkptsDeleted++
+		if bp.Trace != nil && len(BreakpointFindByPos(bp.Pos)) == 0 {
+			if advance != nil && advance.loc.Trace == bp.Trace {
+				// "advance" still needs to stop there; it clears
+				// the statement when it is done.
+				advance.wasSet = false
+			} else {
+				bp.Trace.Breakpoint = false
+			}
+		}
+		if bp.Watch != nil && !watchpointsLeft() {
+			// Stop hearing about writes.
+			interp.SetWriteHook(nil)
+		}
 		return true
 	}
 	return false
@@ -82,6 +132,23 @@ func BreakpointFindByPos(pos token.Pos) []int {
 	return results
 }
 
+// BreakpointCondTrue reports whether the condition of breakpoint bp,
+// if any, holds in frame fr. If the condition can't be compiled or
+// evaluated we say so once and then, as gdb does, drop the condition
+// so that the breakpoint is treated as unconditional from then on.
+func BreakpointCondTrue(bp *Breakpoint, fr *interp.Frame) bool {
+	if bp.Condition == "" { return true }
+	ok, err := EvalBoolInFrame(fr, bp.Condition)
+	if err != nil {
+		Errmsg("Error in testing condition for breakpoint %d: %s", bp.Id,
+			err.Error())
+		Msg("Breakpoint %d is now unconditional.", bp.Id)
+		bp.Condition = ""
+		return true
+	}
+	return ok
+}
+
 func BreakpointFindById(bpNum int) *Breakpoint {
 	for _, bp := range Breakpoints {
 		if bp.Id == bpNum { return bp }
@@ -114,10 +181,20 @@ func Bpprint(bp Breakpoint) {
 	enabled := "n "
 	if bp.Enabled { enabled = "y " }
 
-	loc  := ssa2.FmtRange(curFrame.Fn(), bp.Pos, bp.EndP)
-    mess := fmt.Sprintf("%3d breakpoint    %s  %sat %s",
-		bp.Id, disp, enabled, loc)
-	Msg(mess)
+	if bp.Watch != nil {
+		Msg("%3d watchpoint    %s  %s   %s", bp.Id, disp, enabled,
+			bp.Watch.Expr)
+	} else if bp.Kind == "Catchpoint" {
+		Msg("%3d catchpoint    %s  %s   %s", bp.Id, disp, enabled,
+			catchName(bp.Catch))
+	} else {
+		what := "breakpoint"
+		if bp.Log != "" { what = "logpoint  " }
+		loc  := ssa2.FmtRange(curFrame.Fn(), bp.Pos, bp.EndP)
+		mess := fmt.Sprintf("%3d %s    %s  %sat %s",
+			bp.Id, what, disp, enabled, loc)
+		Msg(mess)
+	}
 
     // line_loc = '%s:%d' %
     //   [iseq.source_container.join(' '),
@@ -132,12 +209,19 @@ func Bpprint(bp Breakpoint) {
     // Msg(mess + loc)
     // Msg("\t#{other_loc}") if verbose
 
-    // if bp.condition && bp.condition != 'true'
-    //   Msg("\tstop %s %s" %
-    //       [bp.negate ? "unless" : "only if", bp.condition])
-    // end
-    if bp.Ignore > 0 {
-		Msg("\tignore next %d hits", bp.Ignore)
+	if bp.Log != "" {
+		Msg("\tlog %s", strconv.Quote(bp.Log))
+	}
+	if bp.GoNum >= 0 {
+		Msg("\tstop only in goroutine %d", bp.GoNum)
+	}
+	if bp.Condition != "" {
+		Msg("\tstop only if %s", bp.Condition)
+	}
+	if bp.Ignore > 0 {
+		ss := ""
+		if bp.Ignore > 1 { ss = "s" }
+		Msg("\tignore next %d hit%s", bp.Ignore, ss)
 	}
     if bp.Hits > 0 {
 		ss := ""
@@ -145,4 +229,56 @@ func Bpprint(bp Breakpoint) {
 		Msg("\tbreakpoint already hit %d time%s",
 			bp.Hits, ss)
 	}
+	for _, line := range bp.Commands {
+		Msg("        %s", line)
+	}
+}
+
+// breakpointLocation gives a location for bp in a form that the
+// "breakpoint" command accepts.
+func breakpointLocation(bp *Breakpoint) string {
+	if bp.Fn != nil {
+		name := bp.Fn.String()
+		if fn, err := LookupFunction(name); err == nil && fn == bp.Fn {
+			return name
+		}
+	}
+	pos := program.Fset.Position(bp.Pos)
+	return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+}
+
+// BreakpointCommands gives the debugger commands that set up bp
+// again, in a program that is started afresh. Watchpoints depend on
+// the state of the running program, so they aren't included.
+func BreakpointCommands(bp *Breakpoint) []string {
+	if bp.Deleted || bp.Watch != nil { return nil }
+	var cmd string
+	switch {
+	case bp.Kind == "Catchpoint":
+		cmd = "catch " + catchName(bp.Catch)
+	case bp.Log != "":
+		loc := breakpointLocation(bp)
+		cmd = fmt.Sprintf("logpoint %s %s", loc, strconv.Quote(bp.Log))
+	case bp.Temp:
+		cmd = "tbreak " + breakpointLocation(bp)
+	default:
+		cmd = "breakpoint " + breakpointLocation(bp)
+	}
+	if bp.GoNum >= 0 && bp.Kind != "Catchpoint" && bp.Log == "" {
+		cmd += fmt.Sprintf(" goroutine %d", bp.GoNum)
+	}
+	if bp.Condition != "" { cmd += " if " + bp.Condition }
+	cmds := []string{cmd}
+	if bp.Ignore > 0 {
+		cmds = append(cmds, fmt.Sprintf("ignore $bpnum %d", bp.Ignore))
+	}
+	if !bp.Enabled {
+		cmds = append(cmds, "disable $bpnum")
+	}
+	if len(bp.Commands) > 0 {
+		cmds = append(cmds, "commands $bpnum")
+		cmds = append(cmds, bp.Commands...)
+		cmds = append(cmds, "end")
+	}
+	return cmds
 }
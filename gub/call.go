@@ -0,0 +1,372 @@
+// Copyright 2015 Rocky Bernstein.
+// Calling functions of the program from the debugger.
+
+package gub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// CallStop is set if breakpoints, watchpoints and catchpoints stop in
+// functions called from the debugger. Otherwise they are ignored
+// there.
+var CallStop bool
+
+// callDepth counts the calls from the debugger in progress, which
+// run in goroutine callGoNum.
+var callDepth int
+var callGoNum int
+
+// inDebuggerCall reports whether frame fr is running because the
+// debugger called a function. If so, the debugger already holds
+// gubLock.
+func inDebuggerCall(fr *interp.Frame) bool {
+	return callDepth > 0 && fr.GoNum() == callGoNum
+}
+
+// callStopEvent reports whether event stops in a function called
+// from the debugger, when CallStop is set.
+func callStopEvent(instr *ssa2.Instruction, event ssa2.TraceEvent) bool {
+	return atBreakpoint(instr, event) || event == ssa2.WATCHPOINT ||
+		isCatchEvent(event)
+}
+
+// stopState is where the debugger is stopped. A stop inside a
+// function called from the debugger changes it, so it is saved around
+// calls.
+type stopState struct {
+	stopFrame, topFrame *interp.Frame
+	curFrame            *interp.Frame
+	curScope            *ssa2.Scope
+	topBlock, curBlock  *ssa2.BasicBlock
+	stackSize           int
+	frameIndex          int
+	instr               *ssa2.Instruction
+	event               ssa2.TraceEvent
+	bpnum               int
+	watch               *Breakpoint
+	inCmdLoop           bool
+}
+
+func saveStop() stopState {
+	return stopState{
+		stopFrame: stopFrame, topFrame: topFrame, curFrame: curFrame,
+		curScope: curScope,
+		topBlock: topBlock, curBlock: curBlock,
+		stackSize: stackSize, frameIndex: frameIndex,
+		instr: Instr, event: TraceEvent,
+		bpnum: curBpnum, watch: curWatch,
+		inCmdLoop: InCmdLoop,
+	}
+}
+
+func (s *stopState) restore() {
+	stopFrame, topFrame = s.stopFrame, s.topFrame
+	curFrame, curScope = s.curFrame, s.curScope
+	topBlock, curBlock = s.topBlock, s.curBlock
+	stackSize, frameIndex = s.stackSize, s.frameIndex
+	Instr, TraceEvent = s.instr, s.event
+	curBpnum, curWatch = s.bpnum, s.watch
+	InCmdLoop = s.inCmdLoop
+}
+
+// errNotCallee is the error for a call expression whose function
+// isn't a function of the program, such as a conversion or a call of
+// a built-in. eval handles those.
+var errNotCallee = errors.New("not a function of the program")
+
+// A callee is a function of the program to call.
+type callee struct {
+	fn       interp.Value // *ssa2.Function or closure
+	sig      *types.Signature
+	recv     interp.Value // receiver, for a method
+	isMethod bool
+}
+
+// exprString gives the source text of expr.
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+// findMethod finds method name of type typ, or returns nil.
+func findMethod(typ types.Type, pkg *types.Package, name string) (fn *ssa2.Function) {
+	// LookupMethod panics when the method isn't in the method set.
+	defer func() {
+		if x := recover(); x != nil { fn = nil }
+	}()
+	return program.LookupMethod(typ, pkg, name)
+}
+
+// ifaceCallee finds method name of the interface value at lv, whose
+// type is itype. The method called is that of the value's dynamic
+// type. It returns nil if the interface has no such method.
+func ifaceCallee(fr *interp.Frame, lv *lvalue, itype *types.Interface, name string) (*callee, error) {
+	for i := 0; i < itype.NumMethods(); i++ {
+		meth := itype.Method(i)
+		if meth.Name() != name { continue }
+		v, ok := lv.read()
+		if !ok { return nil, errNoLocation }
+		fn, recv, err := interp.InterfaceMethod(fr, v, meth)
+		if err != nil { return nil, err }
+		return &callee{fn: fn, sig: meth.Type().(*types.Signature), recv: recv,
+			isMethod: true}, nil
+	}
+	return nil, nil
+}
+
+// methodCallee finds method name of the value at lv. It returns nil
+// if there is no such method.
+func methodCallee(fr *interp.Frame, lv *lvalue, name string) (*callee, error) {
+	if itype, ok := lv.typ.Underlying().(*types.Interface); ok {
+		return ifaceCallee(fr, lv, itype, name)
+	}
+	pkg := fr.Fn().Pkg.Object
+	if fn := findMethod(lv.typ, pkg, name); fn != nil {
+		recv, ok := lv.read()
+		if !ok { return nil, errNoLocation }
+		if recv != nil { recv = interp.CopyVal(recv) }
+		return &callee{fn: fn, sig: fn.Signature, recv: recv, isMethod: true}, nil
+	}
+	if _, ok := lv.typ.Underlying().(*types.Pointer); ok { return nil, nil }
+	fn := findMethod(types.NewPointer(lv.typ), pkg, name)
+	if fn == nil { return nil, nil }
+	if lv.addr == nil {
+		return nil, fmt.Errorf("(*%s).%s has a pointer receiver; call it through a pointer",
+			lv.typ, name)
+	}
+	addr := lv.addr()
+	if addr == nil { return nil, errNoLocation }
+	return &callee{fn: fn, sig: fn.Signature, recv: addr, isMethod: true}, nil
+}
+
+// lookupCallee finds the function that fun, the function part of a
+// call expression, refers to in frame fr: a function, a method of a
+// variable, or a variable or field holding a function or closure.
+func lookupCallee(fr *interp.Frame, fun ast.Expr) (*callee, error) {
+	switch e := fun.(type) {
+	case *ast.ParenExpr:
+		return lookupCallee(fr, e.X)
+	case *ast.Ident:
+		if nameVal, _, _ := EnvLookup(fr, e.Name, curScope); nameVal == nil {
+			if fn := fr.Fn().Pkg.Func(e.Name); fn != nil {
+				return &callee{fn: fn, sig: fn.Signature}, nil
+			}
+			return nil, errNotCallee
+		}
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if nameVal, _, _ := EnvLookup(fr, id.Name, curScope); nameVal == nil {
+				if pkg := PackageByPathOrName(id.Name); pkg != nil {
+					if fn := pkg.Func(e.Sel.Name); fn != nil {
+						return &callee{fn: fn, sig: fn.Signature}, nil
+					}
+					return nil, errNotCallee
+				}
+			}
+		}
+		if lv, err := resolveLvalue(fr, e.X); err == nil {
+			if c, err := methodCallee(fr, lv, e.Sel.Name); c != nil || err != nil {
+				return c, err
+			}
+		}
+	}
+	// A variable or field holding a function.
+	lv, err := resolveLvalue(fr, fun)
+	if err != nil { return nil, errNotCallee }
+	sig, ok := lv.typ.Underlying().(*types.Signature)
+	if !ok { return nil, errNotCallee }
+	v, ok := lv.read()
+	if !ok || v == nil {
+		return nil, fmt.Errorf("%s is nil", exprString(fun))
+	}
+	return &callee{fn: v, sig: sig}, nil
+}
+
+// callArgs computes the arguments for a call of c in frame fr.
+func callArgs(fr *interp.Frame, c *callee, call *ast.CallExpr) ([]interp.Value, error) {
+	params := c.sig.Params()
+	n := params.Len()
+	variadic := c.sig.Variadic() && !call.Ellipsis.IsValid()
+	if (variadic && len(call.Args) < n-1) || (!variadic && len(call.Args) != n) {
+		return nil, fmt.Errorf("wrong number of arguments in call to %s: have %d, want %d",
+			exprString(call.Fun), len(call.Args), n)
+	}
+	var vals []interp.Value
+	if c.isMethod { vals = append(vals, c.recv) }
+	fixed := len(vals) + n
+	if variadic { fixed-- }
+	for i, arg := range call.Args {
+		typ := params.At(i).Type()
+		if variadic && i >= n-1 {
+			typ = params.At(n-1).Type().(*types.Slice).Elem()
+		}
+		v, err := assignValue(fr, exprString(arg), typ)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %s", i+1, err.Error())
+		}
+		vals = append(vals, v)
+	}
+	if variadic {
+		rest := params.At(n-1).Type()
+		if len(vals) == fixed {
+			vals = append(vals, interp.Zero(rest))
+		} else {
+			extra := append([]interp.Value{}, vals[fixed:]...)
+			vals = append(vals[:fixed], extra)
+		}
+	}
+	return vals, nil
+}
+
+// callExpr calls the function of call in frame fr and returns its
+// results and their types. It returns errNotCallee if call doesn't
+// call a function of the program.
+func callExpr(fr *interp.Frame, call *ast.CallExpr) ([]interp.Value, *types.Tuple, error) {
+	c, err := lookupCallee(fr, call.Fun)
+	if err != nil { return nil, nil, err }
+	args, err := callArgs(fr, c, call)
+	if err != nil { return nil, nil, err }
+	if Replaying() {
+		return nil, nil, errors.New("can't call functions while going over recorded history")
+	}
+	saved := saveStop()
+	saveGoNum := callGoNum
+	callDepth++
+	callGoNum = fr.GoNum()
+	defer func() {
+		callDepth--
+		callGoNum = saveGoNum
+		saved.restore()
+	}()
+	results, err := interp.CallFunction(fr, c.fn, args)
+	return results, c.sig.Results(), err
+}
+
+// callValue calls the function of call in frame fr for a value of
+// type typ, as part of assignValue.
+func callValue(fr *interp.Frame, call *ast.CallExpr, typ types.Type) (interp.Value, error) {
+	results, rtypes, err := callExpr(fr, call)
+	if err != nil { return nil, err }
+	if len(results) != 1 {
+		return nil, fmt.Errorf("%s doesn't have a single value", exprString(call))
+	}
+	rtype := rtypes.At(0).Type()
+	if types.Identical(rtype, typ) { return results[0], nil }
+	if _, ok := rtype.Underlying().(*types.Basic); ok {
+		return convertForAssign(reflect.ValueOf(results[0]), typ)
+	}
+	return nil, fmt.Errorf("type mismatch: can't use %s as %s", rtype, typ)
+}
+
+// hasCalls reports whether expr calls a function of the program.
+func hasCalls(fr *interp.Frame, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if found || !ok { return !found }
+		if _, err := lookupCallee(fr, call.Fun); err != errNotCallee {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// shortCircuit evaluates e, an && or || expression whose right
+// operand calls functions of the program, in frame fr. The right
+// operand is evaluated, and its calls made, only if Go would.
+func shortCircuit(fr *interp.Frame, e *ast.BinaryExpr) (interp.Value, error) {
+	x, err := EvalBoolInFrame(fr, exprString(e.X))
+	if err != nil { return nil, err }
+	if x == (e.Op == token.LOR) { return x, nil }
+	return EvalBoolInFrame(fr, exprString(e.Y))
+}
+
+// bindCalls calls the functions of the program that expr calls in
+// frame fr. It returns expr rewritten to refer to the results through
+// variables bound in env, for eval to evaluate. So that calls are
+// made only when Go would make them, an && or || whose right operand
+// makes calls is evaluated here too.
+func bindCalls(fr *interp.Frame, expr string, env *interp.EvalEnv) (string, error) {
+	const prefix = "package p; var _ = "
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", prefix+expr, 0)
+	if err != nil { return expr, nil } // eval reports it
+	var nodes []ast.Expr
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.BinaryExpr:
+			if (e.Op != token.LAND && e.Op != token.LOR) || !hasCalls(fr, e.Y) {
+				return true
+			}
+			nodes = append(nodes, e)
+			return false
+		case *ast.CallExpr:
+			if _, err := lookupCallee(fr, e.Fun); err == errNotCallee {
+				return true
+			}
+			nodes = append(nodes, e)
+			return false
+		}
+		return true
+	})
+	if len(nodes) == 0 { return expr, nil }
+	var buf bytes.Buffer
+	last := 0
+	for k, node := range nodes {
+		var v interp.Value
+		switch e := node.(type) {
+		case *ast.BinaryExpr:
+			if v, err = shortCircuit(fr, e); err != nil { return "", err }
+		case *ast.CallExpr:
+			results, _, err := callExpr(fr, e)
+			if err != nil { return "", err }
+			if len(results) != 1 {
+				return "", fmt.Errorf("%s doesn't have a single value", exprString(e))
+			}
+			v = results[0]
+		}
+		name := fmt.Sprintf("gub۰call%d", k+1)
+		env.Bind(name, v)
+		start := fset.Position(node.Pos()).Offset - len(prefix)
+		end := fset.Position(node.End()).Offset - len(prefix)
+		buf.WriteString(expr[last:start])
+		buf.WriteString(name)
+		last = end
+	}
+	buf.WriteString(expr[last:])
+	return buf.String(), nil
+}
+
+// CallInFrame calls the function of call expression exprStr in frame
+// fr. It returns the results, formatted for showing.
+func CallInFrame(fr *interp.Frame, exprStr string) ([]string, error) {
+	expr, err := parser.ParseExpr(exprStr)
+	if err != nil { return nil, err }
+	call, ok := expr.(*ast.CallExpr)
+	if !ok { return nil, errors.New("expecting a function call") }
+	results, rtypes, err := callExpr(fr, call)
+	if err == errNotCallee {
+		return nil, fmt.Errorf("%s is not a function of the program",
+			exprString(call.Fun))
+	}
+	if err != nil { return nil, err }
+	strs := make([]string, len(results))
+	for i, v := range results {
+		strs[i] = interp.ToInspectType(v, rtypes.At(i).Type())
+	}
+	return strs, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 Rocky Bernstein.
+// Catchpoints: stop when a panic, recover, goroutine start or
+// goroutine exit happens.
+
+package gub
+
+import (
+	"fmt"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// CatchEvents maps what can be given to "catch" to the trace event
+// raised by the interpreter.
+var CatchEvents = map[string]ssa2.TraceEvent{
+	"panic"  : ssa2.PANIC,
+	"recover": ssa2.RECOVER,
+	"go"     : ssa2.GOROUTINE_START,
+	"goexit" : ssa2.GOROUTINE_EXIT,
+}
+
+// catchName gives the "catch" name for event.
+func catchName(event ssa2.TraceEvent) string {
+	for name, e := range CatchEvents {
+		if e == event { return name }
+	}
+	return ssa2.Event2Name[event]
+}
+
+// isCatchEvent reports whether event can be stopped at by a
+// catchpoint.
+func isCatchEvent(event ssa2.TraceEvent) bool {
+	switch event {
+	case ssa2.PANIC, ssa2.RECOVER, ssa2.GOROUTINE_START, ssa2.GOROUTINE_EXIT:
+		return true
+	}
+	return false
+}
+
+// CatchpointNew creates a catchpoint for what, one of the names in
+// CatchEvents.
+func CatchpointNew(what string) (*Breakpoint, error) {
+	event, ok := CatchEvents[what]
+	if !ok {
+		return nil, fmt.Errorf("can't catch %s; expecting panic, recover, go or goexit", what)
+	}
+	bp := &Breakpoint {
+		Hits: 0,
+		Id: BreakpointNext(),
+		Ignore: 0,
+		Kind: "Catchpoint",
+		Temp: false,
+		Enabled: true,
+		Catch: event,
+		GoNum: -1,
+	}
+	WatchpointAdd(bp)
+	return bp, nil
+}
+
+// isStepping reports whether we are stepping in fr or in one of
+// its callers.
+func isStepping(fr *interp.Frame) bool {
+	for ; fr != nil; fr = fr.Caller(0) {
+		if interp.Tracing(fr) != interp.TRACE_STEP_NONE { return true }
+	}
+	return false
+}
+
+// catchTriggered decides whether catchable event stops the
+// program. It sets curBpnum when a catchpoint is responsible.
+//
+// A panic stops the program unless there are catchpoints for panics,
+// and their conditions or ignore counts say not to.
+func catchTriggered(fr *interp.Frame, event ssa2.TraceEvent) bool {
+	caught := false
+	for _, bp := range Breakpoints {
+		if bp.Kind != "Catchpoint" || bp.Catch != event { continue }
+		if bp.Deleted || !bp.Enabled { continue }
+		caught = true
+		if !BreakpointCondTrue(bp, fr) { continue }
+		bp.Hits++
+		if bp.Ignore > 0 {
+			bp.Ignore--
+			continue
+		}
+		curBpnum = bp.Id
+		if bp.Temp { BreakpointDelete(bp.Id) }
+		return true
+	}
+	// We've always stopped at a panic when stepping.
+	return event == ssa2.PANIC && (!caught || isStepping(fr))
+}
+
+// printCatch shows what happened at a catchpoint stop.
+func printCatch(fr *interp.Frame, instr *ssa2.Instruction, bp *Breakpoint) {
+	event := bp.Catch
+	prefix := fmt.Sprintf("Catchpoint %d (%s)", bp.Id, catchName(event))
+	switch event {
+	case ssa2.PANIC:
+		mess := fr.PanicString()
+		if instr != nil {
+			if p, ok := (*instr).(*ssa2.Panic); ok {
+				mess = interp.ToInspect(fr.Get(p.X), nil)
+			}
+		}
+		Msg("%s: panic: %s", prefix, mess)
+	case ssa2.RECOVER:
+		if caller := fr.Caller(0); caller != nil {
+			Msg("%s: recovering from panic: %s", prefix,
+				caller.PanicString())
+		} else {
+			Msg("%s: recovering", prefix)
+		}
+	case ssa2.GOROUTINE_START:
+		Msg("%s: starting goroutine %d", prefix, fr.StartedGoNum())
+	case ssa2.GOROUTINE_EXIT:
+		Msg("%s: goroutine %d exiting", prefix, fr.GoNum())
+	}
+}
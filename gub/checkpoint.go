@@ -0,0 +1,97 @@
+// Copyright 2015 Rocky Bernstein.
+// Checkpoints: snapshots of the program to go back to.
+
+package gub
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// Checkpoints holds the checkpoints taken so far. Checkpoint n is
+// Checkpoints[n-1].
+var Checkpoints []*interp.Checkpoint
+
+// restarting is set while we resume at a checkpoint so that the stop
+// there isn't skipped.
+var restarting bool
+
+// CheckpointNew takes a checkpoint where the program is stopped and
+// returns its number.
+func CheckpointNew() (int, error) {
+	if Replaying() {
+		return 0, errors.New("can't checkpoint while going over recorded history")
+	}
+	if Instr == nil {
+		return 0, errors.New("the program isn't stopped at an instruction")
+	}
+	cp, err := interp.NewCheckpoint(stopFrame, *Instr, TraceEvent)
+	if err != nil { return 0, err }
+	Checkpoints = append(Checkpoints, cp)
+	return len(Checkpoints), nil
+}
+
+// CheckpointGet gives checkpoint id, complaining if there isn't one.
+func CheckpointGet(id int) *interp.Checkpoint {
+	if id < 1 || id > len(Checkpoints) {
+		Errmsg("Checkpoint %d doesn't exist", id)
+		return nil
+	}
+	return Checkpoints[id-1]
+}
+
+// CheckpointString describes checkpoint cp.
+func CheckpointString(cp *interp.Checkpoint) string {
+	fr := cp.Frame()
+	s := fmt.Sprintf("%s at %s", fr.Fn(), ssa2.FmtPos(program.Fset, cp.Pos))
+	if fr.GoNum() != 0 {
+		s += fmt.Sprintf(" in goroutine %d", fr.GoNum())
+	}
+	if fr.Status() != interp.StRunning {
+		s += " (function has returned)"
+	}
+	return s
+}
+
+// CheckpointRestart puts the program back the way it was at
+// checkpoint id and resumes it there. It doesn't return unless there
+// is an error.
+func CheckpointRestart(id int) error {
+	cp := CheckpointGet(id)
+	if cp == nil { return nil }
+	if interp.Recording() {
+		// The recorded history doesn't lead to the checkpoint.
+		HistoryPresent()
+		interp.StopRecording()
+		interp.StartRecording()
+	}
+	moved, err := interp.RestoreCheckpoint(stopFrame, cp)
+	if err != nil { return err }
+	if len(moved) > 0 {
+		nums := make([]string, len(moved))
+		for k, goNum := range moved {
+			nums[k] = fmt.Sprintf("%d", goNum)
+		}
+		Msg("Goroutines %s have moved on since the checkpoint; "+
+			"their variables are restored but they are not rewound.",
+			strings.Join(nums, ", "))
+	}
+	// Watched values changed along with everything else; don't treat
+	// that as a change.
+	for _, bp := range Breakpoints {
+		if bp.Watch == nil || bp.Deleted { continue }
+		if v, ok := bp.Watch.read(); ok {
+			bp.Watch.old = interp.CopyValDeep(v)
+		} else {
+			bp.Watch.old = nil
+		}
+	}
+	Msg("Restarting at checkpoint %d, %s", id, CheckpointString(cp))
+	restarting = true
+	interp.ResumeCheckpoint(cp)
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger advance command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "advance"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: AdvanceCommand,
+		Help: `advance *location*
+
+Continue until *location* is reached or the current frame returns.
+*location* is given as for "breakpoint", but no breakpoint is left
+behind. A breakpoint, watchpoint or catchpoint stops earlier.
+
+See also "until", "tbreak" and "continue".
+`,
+		Min_args: 1,
+		Max_args: 2,
+	}
+	gub.AddToCategory("running", name)
+}
+
+// AdvanceCommand implements the debugger command:
+//    advance *location*
+// which continues to *location* without setting a breakpoint there.
+//
+// See also "until" and "tbreak".
+func AdvanceCommand(args []string) {
+	if notWhileReplaying("advance") { return }
+	if cantResume("advance") { return }
+	loc, err := gub.ParseBreakLoc(args[1:])
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	if loc.Fn != nil && loc.Trace == nil && gub.IsExternal(loc.Fn) {
+		gub.Errmsg("Sorry, %s is a built-in external function.", args[1])
+		return
+	}
+	gub.Advance(gub.TopFrame(), loc)
+	gub.Msg("Advancing to %s...", args[1])
+	gub.InCmdLoop = false
+}
@@ -4,7 +4,8 @@
 package gubcmd
 
 import (
-	"strconv"
+	"go/parser"
+	"strings"
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/interp"
 	"github.com/rocky/ssa-interp/gub"
@@ -14,32 +15,68 @@ func init() {
 	name := "breakpoint"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: BreakpointCommand,
-		Help: `breakpoint [*fn* | line [column]]
+		Help: `breakpoint [*location*] [goroutine *n*] [if *expr*]
 
-Set a breakpoint. The target can either be a function name as fn pkg.fn
-or a line and and optional column number. Specifying a column number
-may be useful if there is more than one statement on a line or if you
-want to distinguish parts of a compound statement.
+Set a breakpoint. *location* is one of:
 
-See also "info break", "enable", and "disable".
+   *fn*                    a function: fn, pkg.fn or pkg/path.fn
+   *method*                a method: T.Method, (*T).Method or pkg.(*T).Method
+   *file*:*line*[:*column*]  a line and optional column in file
+   *line* [*column*]        a line and optional column in the current file
+
+Specifying a column number may be useful if there is more than one
+statement on a line or if you want to distinguish parts of a compound
+statement. If there is no statement at the given line, the breakpoint
+is set at the next line that has one.
+
+If "goroutine *n*" is given, the breakpoint stops only goroutine *n*;
+other goroutines pass through it. See "goroutines" for the numbers.
+
+If "if *expr*" is given, the breakpoint stops only when Go expression
+*expr* evaluates to true in the frame that hits the breakpoint.
+
+See also "tbreak", "rbreak", "info break", "enable", "disable", and
+"condition".
 `,
 
 		Min_args: 0,
-		Max_args: 2,
+		Max_args: -1,
 	}
 	gub.AddToCategory("breakpoints", name)
 	gub.AddAlias("break", name)
 	gub.AddAlias("b", name)
 }
 
+// splitCondition separates a trailing "if *expr*" from the location
+// arguments of a breakpoint command. The expression is taken from
+// argstr so that blanks inside it are preserved.
+func splitCondition(args []string, argstr string) ([]string, string, bool) {
+	for i, arg := range args {
+		if arg != "if" || i == 0 { continue }
+		cond := ""
+		if j := strings.Index(argstr, " if "); j >= 0 {
+			cond = strings.TrimSpace(argstr[j+len(" if "):])
+		}
+		if cond == "" {
+			gub.Errmsg("Expecting an expression after \"if\"")
+			return nil, "", false
+		}
+		if _, err := parser.ParseExpr(cond); err != nil {
+			gub.Errmsg("Invalid condition %s: %s", cond, err.Error())
+			return nil, "", false
+		}
+		return args[0:i], cond, true
+	}
+	return args, "", true
+}
+
 // BreakpointCommand implements the debugger command:
-//    breakpoint [*fn* | line [column]
+//    breakpoint [*location*] [goroutine *n*] [if *expr*]
 // which sets a breakpoint.
 //
-// The target can either be a function name as fn pkg.fn
-// or a line and and optional column number. Specifying a column number
-// may be useful if there is more than one statement on a line or if you
-// want to distinguish parts of a compound statement.
+// The location can be a function or method, a file:line[:column], or
+// a line and optional column number in the current file. See
+// gub.ParseBreakLoc.
 //
 // See also "info break", "enable", and "disable" and "delete".
 func BreakpointCommand(args []string) {
@@ -47,82 +84,87 @@ func BreakpointCommand(args []string) {
 		InfoBreakpointSubcmd(args)
 		return
 	}
-	name := args[1]
-	fn := gub.GetFunction(name)
-	if fn != nil {
-		if ext := interp.Externals()[name]; ext != nil {
-			gub.Msg("Sorry, %s is a built-in external function.", name)
-			return
-		}
-		interp.SetFnBreakpoint(fn)
-		bp := &gub.Breakpoint {
-			Hits: 0,
-			Id: gub.BreakpointNext(),
-			Pos: fn.Pos(),
-			EndP: fn.EndP(),
-			Ignore: 0,
-			Kind: "Function",
-			Temp: false,
-			Enabled: true,
-		}
-		bpnum := gub.BreakpointAdd(bp)
-		gub.Msg(" Breakpoint %d set in function %s at %s", bpnum, name,
-			ssa2.FmtRange(fn, fn.Pos(), fn.EndP()))
+	setBreakpoint(args, false)
+}
+
+// setBreakpoint does the work for the "breakpoint" and "tbreak"
+// commands. If temp is true, the breakpoint is deleted after it is
+// first hit.
+func setBreakpoint(args []string, temp bool) {
+	what := "Breakpoint"
+	if temp { what = "Temporary breakpoint" }
+	args, cond, valid := splitCondition(args, " " + gub.CmdArgstr)
+	if !valid { return }
+	args, goNum, valid := splitGoroutine(args)
+	if !valid { return }
+	if len(args) > 3 {
+		gub.Errmsg("Too many args; need at most 2, got %d", len(args)-1)
 		return
 	}
-	line, ok := strconv.Atoi(args[1])
-	if ok != nil {
-		gub.Errmsg("Don't know yet how to deal with a break that doesn't start with a function or integer")
+	loc, err := gub.ParseBreakLoc(args[1:])
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
 		return
 	}
-
-	column := -1
-	if len(args) == 3 {
-		foo, ok := strconv.Atoi(args[2])
-		if ok != nil {
-			gub.Errmsg("Don't know how to deal a non-int argument as 2nd parameter yet")
-			return
-		}
-		column = foo
+	bp := addBreakpoint(loc, what, args[1], temp, cond)
+	if bp != nil && goNum >= 0 {
+		bp.GoNum = goNum
+		gub.Msg("  It stops only in goroutine %d.", goNum)
 	}
+}
 
-	fn = gub.CurFrame().Fn()
-	fset := gub.CurFrame().Fset()
-	position := gub.CurFrame().Position()
-	if position.IsValid() {
-		filename := position.Filename
-		for _, l := range fn.Pkg.Locs() {
-			try := fset.Position(l.Pos())
-			if try.Filename == filename && line == try.Line {
-				if column == -1 || column == try.Column {
-					bp := &gub.Breakpoint {
-						Hits: 0,
-						Id: gub.BreakpointNext(),
-						Pos: l.Pos(),
-						EndP: l.Pos(),
-						Ignore: 0,
-						Kind: "Statement",
-						Temp: false,
-						Enabled: true,
-					}
-					bpnum := gub.BreakpointAdd(bp)
-					if l.Trace != nil {
-						l.Trace.Breakpoint = true
-					} else if l.Fn != nil {
-						l.Fn.Breakpoint = true
-						bp.Kind = "Function"
-					} else {
-						gub.Errmsg("Internal error setting in file %s line %d, column %d",
-							bpnum, filename, line, try.Column)
-						return
-					}
-					gub.Msg("Breakpoint %d set in file %s line %d, column %d", bpnum, filename, line, try.Column)
-					return
-				}
-			}
+// splitGoroutine separates a trailing "goroutine *n*" from the
+// location arguments of a breakpoint command. The goroutine number is
+// -1 if none is given.
+func splitGoroutine(args []string) ([]string, int, bool) {
+	n := len(args)
+	if n < 3 || args[n-2] != "goroutine" { return args, -1, true }
+	goNum, err := gub.GetInt(args[n-1], "goroutine number", 0, 0)
+	if err != nil { return nil, -1, false }
+	return args[:n-2], goNum, true
+}
+
+// addBreakpoint sets a breakpoint at loc and reports it. name is the
+// location as the user gave it. The new breakpoint is returned,
+// or nil if one can't be set at loc.
+func addBreakpoint(loc *gub.BreakLoc, what string, name string, temp bool,
+	cond string) *gub.Breakpoint {
+	bp := &gub.Breakpoint {
+		Hits: 0,
+		Id: gub.BreakpointNext(),
+		Pos: loc.Pos,
+		EndP: loc.EndP,
+		Ignore: 0,
+		Kind: "Statement",
+		Temp: temp,
+		Enabled: true,
+		Condition: cond,
+		GoNum: -1,
+	}
+	if loc.Trace != nil {
+		loc.Trace.Breakpoint = true
+		bp.Trace = loc.Trace
+	} else if fn := loc.Fn; fn != nil {
+		if gub.IsExternal(fn) {
+			gub.Msg("Sorry, %s is a built-in external function.", name)
+			return nil
+		}
+		interp.SetFnBreakpoint(fn)
+		bp.Kind = "Function"
+		bp.Fn = fn
+		if loc.ByName {
+			bpnum := gub.BreakpointAdd(bp)
+			gub.Msg(" %s %d set in function %s at %s", what, bpnum, name,
+				ssa2.FmtRange(fn, fn.Pos(), fn.EndP()))
+			return bp
 		}
-		suffix := ""
-		if column != -1 { suffix = ", column " + args[2] }
-		gub.Errmsg("Can't find statement in file %s at line %d%s", filename, line, suffix)
+	} else {
+		gub.Errmsg("Internal error setting breakpoint")
+		return nil
 	}
+	bpnum := gub.BreakpointAdd(bp)
+	position := loc.Position()
+	gub.Msg("%s %d set in file %s line %d, column %d", what,
+		bpnum, position.Filename, position.Line, position.Column)
+	return bp
 }
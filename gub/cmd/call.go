@@ -0,0 +1,62 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger call command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "call"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CallCommand,
+		Help: `call *fn*(*args*...)
+
+Call function *fn* of the program in the selected frame and show what
+it returns. *fn* can be a function, pkg.function, a method of a
+variable such as x.String, or a variable or field holding a function
+or closure. For example:
+
+   call fib(10)
+   call p.String()
+   call strings.Repeat("ab", n)
+
+The call runs in the goroutine of the selected frame. Breakpoints,
+watchpoints and catchpoints in the called function are ignored unless
+"set callstop" is on; then the debugger stops there and "continue"
+finishes the call. A panic in the called function ends the call but
+not the program.
+
+Calls can also be made in expressions, such as breakpoint
+conditions. As in Go, the right side of && or || is evaluated only
+when needed, so "p != nil && p.Ok()" doesn't call p.Ok when p is nil.
+A method called through an interface is that of the value's dynamic
+type, e.g. "call err.Error()".
+
+See also "set callstop" and "set var".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("data", name)
+}
+
+// CallCommand implements the debugger command:
+//    call *fn*(*args*...)
+// which calls a function of the program.
+//
+// See also "set callstop".
+func CallCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	results, err := gub.CallInFrame(gub.CurFrame(), gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	if len(results) > 0 {
+		gub.Msg("%s", strings.Join(results, ", "))
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger catchpoint command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "catch"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CatchCommand,
+		Help: `catch panic|recover|go|goexit [if *expr*]
+
+Set a catchpoint: stop when an event happens rather than at a
+particular place.
+
+   panic    stop where a panic starts, before any deferred calls run,
+            so the locals of the panicking frame can be inspected.
+            This includes run-time errors like indexing out of range.
+   recover  stop at a recover() call that is about to stop a panic
+   go       stop at a "go" statement, before the goroutine starts
+   goexit   stop just before a goroutine exits, whether its function
+            returned, panicked or called runtime.Goexit
+
+If "if *expr*" is given, the catchpoint stops only when Go expression
+*expr* evaluates to true in the frame where the event happens.
+
+A panic always stops the program when there is no "panic"
+catchpoint. With "panic" catchpoints, it stops only when one of them
+triggers, or when stepping; their conditions and ignore counts let
+uninteresting panics through.
+
+See also "breakpoint", "info break", "delete", and "condition".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// CatchCommand implements the debugger command:
+//    catch panic|recover|go|goexit [if *expr*]
+// which sets a catchpoint.
+//
+// See also "breakpoint", "info break", "delete", and "condition".
+func CatchCommand(args []string) {
+	args, cond, valid := splitCondition(args, " " + gub.CmdArgstr)
+	if !valid { return }
+	if len(args) != 2 {
+		gub.Errmsg("Expecting one of panic, recover, go or goexit")
+		return
+	}
+	bp, err := gub.CatchpointNew(args[1])
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	bp.Condition = cond
+	gub.Msg("Catchpoint %d (%s)", bp.Id, args[1])
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger checkpoint command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "checkpoint"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CheckpointCommand,
+		Help: `checkpoint
+
+Take a snapshot of the program where it is stopped: its package
+variables, the frames of each goroutine, and the variables they
+reach. "restart *id*" later goes back to the snapshot and resumes
+there, without running the program again from the start.
+
+A checkpoint can be taken at a statement or at the entry to a
+function. It can be restarted only while the function it was taken
+in is still running, and only from the same goroutine. Other
+goroutines get their variables back but aren't rewound. The contents
+of channels, and anything outside the program such as files, are not
+saved.
+
+See also "info checkpoints", "restart", and "record".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("ckpt", name)
+}
+
+// CheckpointCommand implements the debugger command:
+//    checkpoint
+// which takes a snapshot of the program to restart from later.
+//
+// See also "info checkpoints" and "restart".
+func CheckpointCommand(args []string) {
+	id, err := gub.CheckpointNew()
+	if err != nil {
+		gub.Errmsg("Can't checkpoint: %s", err.Error())
+		return
+	}
+	gub.Msg("Checkpoint %d: %s", id, gub.CheckpointString(gub.Checkpoints[id-1]))
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger breakpoint command-list command
+
+package gubcmd
+
+import (
+	"strings"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "commands"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: CommandsCommand,
+		Help: `commands [*bpnum*]
+
+Give debugger commands to run each time breakpoint *bpnum* stops the
+program. If *bpnum* is omitted, the most recently set breakpoint is
+used. Type the commands one per line, and finish with a line saying
+just "end". Giving no commands removes any earlier command list.
+
+If the first command is "silent", the location isn't shown when the
+breakpoint is hit. If a command resumes execution, such as "continue"
+or "step", the rest of the list is skipped and the program runs on
+without reading commands. This makes it possible to trace without
+stopping:
+
+    commands 1
+    silent
+    eval x
+    continue
+    end
+
+See also "breakpoint", "info break", and "condition".
+`,
+		Min_args: 0,
+		Max_args: 1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// CommandsCommand implements the debugger command:
+//    commands [*bpnum*]
+// which reads a list of debugger commands, ending with "end", to run
+// when breakpoint *bpnum* is hit.
+//
+// See also "breakpoint", "info break", and "condition".
+func CommandsCommand(args []string) {
+	if gub.IsBreakpointEmpty() {
+		gub.Errmsg("No breakpoints.")
+		return
+	}
+	bpnum := len(gub.Breakpoints) - 1
+	if len(args) == 2 {
+		var err error
+		bpnum, err = gub.GetBpnum(args[1], "breakpoint number")
+		if err != nil { return }
+	}
+	if !gub.BreakpointExists(bpnum) {
+		gub.Errmsg("Breakpoint %d doesn't exist", bpnum)
+		return
+	}
+	gub.Msg("Type commands for when breakpoint %d is hit, one per line.",
+		bpnum)
+	gub.Msg("End with a line saying just \"end\".")
+	cmds := make([]string, 0)
+	for {
+		line, err := gub.ReadLine(">")
+		line = strings.Trim(line, " \t\n")
+		if line == "end" { break }
+		if len(line) > 0 {
+			cmds = append(cmds, line)
+		}
+		if err != nil { break }
+	}
+	gub.Breakpoints[bpnum].Commands = cmds
+}
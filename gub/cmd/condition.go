@@ -0,0 +1,63 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger breakpoint condition command
+
+package gubcmd
+
+import (
+	"go/parser"
+	"strings"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "condition"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ConditionCommand,
+		Help: `condition *bpnum* [*expr*]
+
+Set the condition for breakpoint *bpnum* to Go expression *expr*. The
+breakpoint stops the program only when *expr* evaluates to true in
+the frame that hits it. If *expr* is omitted, the breakpoint becomes
+unconditional.
+
+If the expression can't be evaluated when the breakpoint is hit, an
+error is shown once and the breakpoint becomes unconditional.
+
+See also "breakpoint", and "info break".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+	gub.AddAlias("cond", name)
+}
+
+// ConditionCommand implements the debugger command:
+//    condition *bpnum* [*expr*]
+// which sets or removes the condition on a breakpoint.
+//
+// See also "breakpoint", and "info break".
+func ConditionCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	argstr := strings.TrimSpace(gub.CmdArgstr)
+	bpstr := strings.Fields(argstr)[0]
+	bpnum, err := gub.GetBpnum(bpstr, "breakpoint number")
+	if err != nil { return }
+	if !gub.BreakpointExists(bpnum) {
+		gub.Errmsg("Breakpoint %d doesn't exist", bpnum)
+		return
+	}
+	bp := gub.Breakpoints[bpnum]
+	cond := strings.TrimSpace(strings.TrimPrefix(argstr, bpstr))
+	if cond == "" {
+		bp.Condition = ""
+		gub.Msg("Breakpoint %d now unconditional.", bpnum)
+		return
+	}
+	if _, err := parser.ParseExpr(cond); err != nil {
+		gub.Errmsg("Invalid condition %s: %s", cond, err.Error())
+		return
+	}
+	bp.Condition = cond
+	gub.Msg("Breakpoint %d stops only if %s", bpnum, cond)
+}
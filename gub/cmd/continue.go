@@ -3,6 +3,7 @@
 package gubcmd
 
 import (
+	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/gub"
 	"github.com/rocky/ssa-interp/interp"
 )
@@ -16,6 +17,10 @@ func init() {
 Leave the debugger loop and continue execution. Subsequent entry to
 the debugger however may occur via breakpoints or explicit calls, or
 exceptions.
+
+If we have gone back in the recorded execution history, this goes
+forward over it to the next breakpoint, or to the present. See also
+"reverse-continue".
 `,
 		Min_args: 0,
 		Max_args: 0,
@@ -25,9 +30,31 @@ exceptions.
 }
 
 func ContinueCommand(args []string) {
+	if gub.Replaying() {
+		gub.HistoryForward(gub.HISTORY_CONTINUE)
+		return
+	}
+	if cantResume("continue") { return }
 	for fr := gub.TopFrame(); fr != nil; fr = fr.Caller(0) {
 		interp.SetStepOff(fr)
 	}
+	// The goroutine stopped in may not be the one selected.
+	for fr := gub.StopFrame(); fr != nil; fr = fr.Caller(0) {
+		interp.SetStepOff(fr)
+	}
 	gub.InCmdLoop = false
 	gub.Msg("Continuing...")
 }
+
+// cantResume reports, with an error, whether the program stopped
+// where it can't be resumed: after an unrecovered panic or a
+// deadlock.
+func cantResume(name string) bool {
+	switch gub.TraceEvent {
+	case ssa2.POSTMORTEM, ssa2.DEADLOCK:
+		gub.Errmsg("Can't use \"%s\"; the program is dead and can't be resumed.",
+			name)
+		return true
+	}
+	return false
+}
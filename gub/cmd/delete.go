@@ -33,7 +33,7 @@ Delete a breakpoint by breakpoint number.
 func DeleteCommand(args []string) {
 	for i:=1; i<len(args); i++ {
 		msg := fmt.Sprintf("breakpoint number for argument %d", i)
-		bpnum, err := gub.GetInt(args[i], msg, 0, len(gub.Breakpoints)-1)
+		bpnum, err := gub.GetBpnum(args[i], msg)
 		if err != nil { continue }
 		if gub.BreakpointExists(bpnum) {
 			if gub.BreakpointDelete(bpnum) {
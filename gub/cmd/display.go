@@ -0,0 +1,55 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger display command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "display"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: DisplayCommand,
+		Help: `display [*expr*]
+
+Show the value of Go expression *expr* now and every time the program
+stops. If *expr* uses local variables, it is shown only when the
+program stops where those variables are in scope, in the function the
+display was set in.
+
+Without an argument, show the current values of all displays that are
+in scope.
+
+When "set displaychanged" is on, values that changed since the last
+time they were shown are highlighted.
+
+See also "undisplay", "info display" and "set displaychanged".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("data", name)
+	gub.AddAlias("disp", name)
+}
+
+// DisplayCommand implements the debugger command:
+//    display [*expr*]
+// which shows the value of *expr* whenever the program stops.
+//
+// See also "undisplay" and "info display".
+func DisplayCommand(args []string) {
+	if len(args) == 1 {
+		gub.ShowDisplays()
+		return
+	}
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	d, err := gub.DisplayAdd(gub.CurFrame(), gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg("Can't display %s: %s", gub.CmdArgstr, err.Error())
+		return
+	}
+	if gub.CurFrame() != nil {
+		gub.ShowDisplay(gub.CurFrame(), d)
+	}
+}
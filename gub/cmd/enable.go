@@ -30,7 +30,7 @@ Enable a breakpoint by the number assigned to it.`,
 func EnableCommand(args []string) {
 	for i:=1; i<len(args); i++ {
 		msg := fmt.Sprintf("breakpoint number for argument %d", i)
-		bpnum, err := gub.GetInt(args[i], msg, 0, len(gub.Breakpoints)-1)
+		bpnum, err := gub.GetBpnum(args[i], msg)
 		if err != nil { continue }
 		if gub.BreakpointExists(bpnum) {
 			if gub.BreakpointIsEnabled(bpnum) {
@@ -0,0 +1,44 @@
+// Copyright 2013-2015 Rocky Bernstein.
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "eval"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: EvalCommand,
+		Help: `eval *expr*
+
+Evaluate Go expression *expr* in the selected frame and show its
+value. *expr* can use the local variables of the frame and the
+package variables, constants and functions of its package. For
+example:
+
+   eval a + b
+   eval len(s) > 2 && s[2] == "x"
+
+See also "call", "display", "locals" and "whatis".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("data", name)
+	gub.AddAlias("print", name)
+	gub.AddAlias("p", name)
+}
+
+// EvalCommand implements the debugger command:
+//    eval *expr*
+// which evaluates Go expression *expr*.
+func EvalCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks inside quotes
+	results, err := gub.EvalExprInFrame(gub.CurFrame(), gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	gub.Msg("%s", gub.FormatResults(results))
+}
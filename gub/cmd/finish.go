@@ -4,7 +4,6 @@ package gubcmd
 
 import (
 	"github.com/rocky/ssa-interp/gub"
-	"github.com/rocky/ssa-interp/interp"
 )
 
 func init() {
@@ -20,6 +19,8 @@ Continue execution until the program is about to:
   yielded to.
 
 Sometimes this is called 'step out'.
+
+Only the goroutine selected, see "goroutine", is stepped.
 `,
 		Min_args: 0,
 		Max_args: 0,
@@ -30,7 +31,9 @@ Sometimes this is called 'step out'.
 }
 
 func FinishCommand(args []string) {
-	interp.SetStepOut(gub.TopFrame())
+	if notWhileReplaying("finish") { return }
+	if cantResume("finish") { return }
+	gub.Finish(gub.TopFrame())
 	gub.Msg("Continuing until return...")
 	gub.InCmdLoop = false
 }
@@ -22,14 +22,12 @@ statement that we are stopped at. This could be for example an
 assignment statement, a for loop, a condition in a for loop, or
 initializer in a for loop, to name just a few examples.
 
-See also the "ast" command.
+See also the "ast" and "list" commands.
 `,
 		Min_args: 0,
 		Max_args: 1,
 	}
 	gub.AddToCategory("files", name)
-	gub.AddAlias("list", name)
-	gub.AddAlias("l", name)
 }
 
 // FormatCommand implements the debugger command: format
@@ -0,0 +1,51 @@
+// Copyright 2015 Rocky Bernstein.
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "goroutine"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: GoroutineCommand,
+		Help: `goroutine [*num*]
+
+Select goroutine *num*: its innermost frame becomes the current
+frame, and "step", "next", "finish" and the frame commands apply to
+it. Without a parameter, show the goroutine selected.
+
+The goroutine the program stopped in stays stopped too. Stepping in
+one goroutine lets the others run, but only step stops in the
+goroutine selected stop the program.
+
+See also "goroutines", "set nonstop" and "break ... goroutine *num*".
+`,
+		Min_args: 0,
+		Max_args: 1,
+	}
+	gub.AddToCategory("stack", name)
+	gub.AddAlias("go", name)
+}
+
+func GoroutineCommand(args []string) {
+	if len(args) == 1 {
+		goNum := gub.TopFrame().GoNum()
+		if goNum == gub.StopFrame().GoNum() {
+			gub.Msg("Goroutine %d is selected; the program stopped in it.", goNum)
+		} else {
+			gub.Msg("Goroutine %d is selected; the program stopped in goroutine %d.",
+				goNum, gub.StopFrame().GoNum())
+		}
+		return
+	}
+	goTops := interp.GetInterpreter().GoTops()
+	goNum, err := gub.GetInt(args[1],
+		"goroutine number", 0, len(goTops)-1)
+	if err != nil { return }
+	if err := gub.SelectGoroutine(goNum); err != nil {
+		gub.Errmsg(err.Error())
+	}
+}
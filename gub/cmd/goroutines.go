@@ -15,6 +15,10 @@ func init() {
 
 Without a parameter, list stack traces for each active goroutine. If an id
 is given only that goroutine stack trace is shown. The main (first) goroutine is 0.
+
+A goroutine blocked in a channel operation, a select or a semaphore of
+package sync is shown with what it is blocked in, as in
+"Goroutine 1 [chan send]".
 `,
 		Min_args: 0,
 		Max_args: 1,
@@ -23,7 +27,6 @@ is given only that goroutine stack trace is shown. The main (first) goroutine is
 	gub.AddAlias("gore", name)
 	// Down the line we'll have abbrevs
 	gub.AddAlias("gor", name)
-	gub.AddAlias("goroutine", name)
 }
 
 // shows stack of all goroutines
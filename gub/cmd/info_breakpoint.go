@@ -20,7 +20,10 @@ the breakpoint after it gets hit.
 The "enb" column indicates whether the breakpoint is enabled.
 
 The "Where" column indicates where the breakpoint is located.
-Status of user-settable breakpoints.
+
+Below each breakpoint we show its condition, the number of hits still
+to be ignored, how many times it has been hit, and its command list,
+when these apply.
 `,
 		Min_args: 0,
 		Max_args: -1,
@@ -51,6 +54,7 @@ func InfoBreakpointSubcmd(args [] string) {
 	bpLen := len(gub.Breakpoints)
 	if bpLen - gub.BrkptsDeleted == 0 {
 		gub.Msg("No breakpoints.")
+		return
 	}
 	if len(args) > 2 {
 		headerShown := false
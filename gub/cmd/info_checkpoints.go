@@ -0,0 +1,46 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info checkpoints
+//
+// Lists the checkpoints taken
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoCheckpointsSubcmd,
+		Help: `info checkpoints
+
+List the checkpoints taken, with where each was taken, how many
+goroutines were running then, and how many variables it saved.
+Checkpoints in a function that has returned can no longer be
+restarted.
+
+See also "checkpoint" and "restart".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Checkpoints taken",
+		Name: "checkpoints",
+	})
+}
+
+// InfoCheckpointsSubcmd implements the debugger command:
+//   info checkpoints
+// which lists the checkpoints taken.
+func InfoCheckpointsSubcmd(args []string) {
+	if len(gub.Checkpoints) == 0 {
+		gub.Msg("No checkpoints.")
+		return
+	}
+	for k, cp := range gub.Checkpoints {
+		gub.Msg("%3d  %s", k+1, gub.CheckpointString(cp))
+		gub.Msg("     %d goroutine(s), %d variable(s) saved",
+			cp.Goroutines(), cp.Variables())
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info display
+//
+// Lists the expressions displayed when the program stops
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoDisplaySubcmd,
+		Help: `info display
+
+List the expressions shown when the program stops. A display that
+uses local variables gives the function it applies to, and is marked
+with "*" when those variables are not in scope where the program is
+stopped now.
+
+See also "display" and "undisplay".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Expressions to display when the program stops",
+		Name: "display",
+	})
+}
+
+// InfoDisplaySubcmd implements the debugger command:
+//   info display
+// which lists the displays.
+func InfoDisplaySubcmd(args []string) {
+	if len(gub.Displays) == 0 {
+		gub.Msg("No displays.")
+		return
+	}
+	gub.Section("Auto-display expressions now in effect:")
+	for _, d := range gub.Displays {
+		mark := " "
+		if !gub.DisplayInScope(d, gub.CurFrame(), gub.CurScope()) { mark = "*" }
+		gub.Msg("%3d%s %s", d.Id, mark, gub.DisplayString(d))
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info functions [*regexp*]
+//
+// Lists the functions of the program matching a regular expression
+
+package gubcmd
+
+import (
+	"regexp"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoFunctionsSubcmd,
+		Help: `info functions [*regexp*]
+
+List the functions and methods of all packages of the program whose
+qualified names, such as main.gcd or (*bytes.Buffer).Write, match
+*regexp*, or all of them if none is given. Synthetic functions, like
+the wrappers built for promoted methods and method values, are
+included and marked with what they are for. Each is shown with its
+signature and position.
+
+See also "info variables", "info types", "info methods" and "rbreak".
+`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "Functions of the program matching a regexp",
+		Name: "functions",
+	})
+}
+
+// InfoFunctionsSubcmd implements the debugger command:
+//   info functions [*regexp*]
+// which lists the functions of the program whose names match *regexp*.
+func InfoFunctionsSubcmd(args []string) {
+	re, pattern, ok := symbolRegexp(args)
+	if !ok { return }
+	listSymbols("functions", pattern, gub.FunctionSymbols(re))
+}
+
+// symbolRegexp compiles the regular expression argument of a symbol
+// search. With no argument, everything matches.
+func symbolRegexp(args []string) (*regexp.Regexp, string, bool) {
+	pattern := ""
+	if len(args) > 2 { pattern = args[2] }
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		gub.Errmsg("Invalid regular expression %s: %s", pattern, err.Error())
+		return nil, "", false
+	}
+	return re, pattern, true
+}
+
+// listSymbols shows the results of a search for what matching
+// pattern.
+func listSymbols(what string, pattern string, syms []gub.Symbol) {
+	if len(syms) == 0 {
+		if pattern == "" {
+			gub.Msg("No %s.", what)
+		} else {
+			gub.Msg("No %s match %s.", what, pattern)
+		}
+		return
+	}
+	if pattern == "" {
+		gub.Section("All %s:", what)
+	} else {
+		gub.Section("All %s matching %s:", what, pattern)
+	}
+	for _, sym := range syms {
+		gub.Msg("%s %s", sym.Name, sym.Desc)
+		gub.Msg("\t%s", sym.Where)
+	}
+}
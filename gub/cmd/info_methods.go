@@ -0,0 +1,54 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info methods *type*
+//
+// Lists the methods of a type
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoMethodsSubcmd,
+		Help: `info methods *type*
+
+List the method set of named type *type*, given as T, *T, pkg.T or
+pkg/path.T. For a type that isn't an interface, the methods of *T are
+listed, since they include those of T. Methods promoted from embedded
+fields, and those with a value receiver called through a pointer, are
+synthetic wrappers and are marked as such. Each method is shown with
+its signature and position.
+
+See also "info types" and "info functions".
+`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "Methods of a type",
+		Name: "methods",
+	})
+}
+
+// InfoMethodsSubcmd implements the debugger command:
+//   info methods *type*
+// which lists the methods of *type*.
+func InfoMethodsSubcmd(args []string) {
+	typ, err := gub.LookupType(args[2])
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	syms := gub.MethodSymbols(typ)
+	if len(syms) == 0 {
+		gub.Msg("%s has no methods.", args[2])
+		return
+	}
+	gub.Section("Methods of %s:", args[2])
+	for _, sym := range syms {
+		gub.Msg("%s %s", sym.Name, sym.Desc)
+		gub.Msg("\t%s", sym.Where)
+	}
+}
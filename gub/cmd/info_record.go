@@ -0,0 +1,50 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info record
+//
+// Shows the state of execution-history recording
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoRecordSubcmd,
+		Help: `info record
+
+Show whether execution history is being recorded, how many statements
+it covers, how much memory it uses, and where in it we are.
+
+See also "record" and "set recordlimit".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Execution history recording",
+		Name: "record",
+	})
+}
+
+// InfoRecordSubcmd implements the debugger command:
+//   info record
+// which shows the state of execution-history recording.
+func InfoRecordSubcmd(args []string) {
+	if !interp.Recording() {
+		gub.Msg("Not recording execution history.")
+		return
+	}
+	n := interp.HistoryLen()
+	gub.Msg("Recording execution history.")
+	gub.Msg("Statements recorded: %d", n)
+	gub.Msg("Memory used: about %s of %s", formatSize(interp.HistorySize()),
+		formatSize(interp.HistoryBudget()))
+	if gub.Replaying() {
+		gub.Msg("Replaying: at statement %d of %d", interp.HistoryCursor()+1, n)
+	} else {
+		gub.Msg("At the present.")
+	}
+}
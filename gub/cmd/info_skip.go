@@ -0,0 +1,48 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info skip
+//
+// Lists the functions that stepping skips
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoSkipSubcmd,
+		Help: `info skip
+
+List the functions, packages and files that "step" steps over rather
+than into, and whether GOROOT packages are skipped.
+
+See also "skip".
+`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "Functions that stepping skips",
+		Name: "skip",
+	})
+}
+
+// InfoSkipSubcmd implements the debugger command:
+//   info skip
+// which lists the skips.
+func InfoSkipSubcmd(args []string) {
+	if gub.SkipGoroot {
+		gub.Msg("Packages in GOROOT are skipped.")
+	} else {
+		gub.Msg("Packages in GOROOT are not skipped.")
+	}
+	if len(gub.Skips) == 0 {
+		gub.Msg("No skips.")
+		return
+	}
+	gub.Section("Num Kind     Pattern")
+	for _, skip := range gub.Skips {
+		gub.Msg("%3d %-8s %s", skip.Id, skip.Kind, skip.Pattern)
+	}
+}
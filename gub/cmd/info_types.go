@@ -0,0 +1,40 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info types [*regexp*]
+//
+// Lists the types of the program matching a regular expression
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoTypesSubcmd,
+		Help: `info types [*regexp*]
+
+List the named types declared at package level in all packages of the
+program whose qualified names, such as main.Point or bytes.Buffer,
+match *regexp*, or all of them if none is given. Each is shown with
+its underlying type and position.
+
+See also "info methods", "info functions" and "whatis".
+`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "Named types matching a regexp",
+		Name: "types",
+	})
+}
+
+// InfoTypesSubcmd implements the debugger command:
+//   info types [*regexp*]
+// which lists the types of the program whose names match *regexp*.
+func InfoTypesSubcmd(args []string) {
+	re, pattern, ok := symbolRegexp(args)
+	if !ok { return }
+	listSymbols("types", pattern, gub.TypeSymbols(re))
+}
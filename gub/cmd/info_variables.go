@@ -0,0 +1,39 @@
+// Copyright 2015 Rocky Bernstein.
+
+// info variables [*regexp*]
+//
+// Lists the package variables of the program matching a regular expression
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "info"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: InfoVariablesSubcmd,
+		Help: `info variables [*regexp*]
+
+List the package variables of all packages of the program whose
+qualified names, such as main.count or os.Args, match *regexp*, or all
+of them if none is given. Each is shown with its type and position.
+
+See also "info functions", "info types" and "whatis".
+`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "Package variables matching a regexp",
+		Name: "variables",
+	})
+}
+
+// InfoVariablesSubcmd implements the debugger command:
+//   info variables [*regexp*]
+// which lists the package variables of the program whose names match *regexp*.
+func InfoVariablesSubcmd(args []string) {
+	re, pattern, ok := symbolRegexp(args)
+	if !ok { return }
+	listSymbols("package variables", pattern, gub.VariableSymbols(re))
+}
@@ -20,6 +20,8 @@ Jumps to instruction *num* inside the current basic block.
 }
 
 func JumpCommand(args []string) {
+	if notWhileReplaying("jump") { return }
+	if cantResume("jump") { return }
 	fr := gub.CurFrame()
 	b := gub.CurBlock()
 	ic, err := gub.GetInt(args[1],
@@ -0,0 +1,74 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger list command
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "list"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ListCommand,
+		Help: `list [*location*|-|*first*,[*last*]]
+
+List source lines. With no argument, list ten lines around where the
+program is stopped, or if a listing has been made since then, the ten
+lines after it. Other forms are:
+
+   list -            the ten lines before the last listing
+   list *fn*          ten lines around the start of function *fn*
+   list *file*:*line*   ten lines around *line* of *file*
+   list *line*        ten lines around *line* of the current file
+   list *first*,*last*  lines *first* through *last*
+   list *first*,      ten lines starting at *first*
+   list ,*last*       ten lines ending at *last*
+
+*first* can be any of the locations above. Functions are given as for
+"breakpoint".
+
+The line where the program is stopped in the selected frame is marked
+with "->". Lines with a breakpoint are marked with "B", or "b" if all
+of their breakpoints are disabled. Source is highlighted when "set
+highlight" is on.
+
+See also "format", "breakpoint" and "frame".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("files", name)
+	gub.AddAlias("l", name)
+}
+
+// ListCommand implements the debugger command:
+//    list [*location*|-|*first*,[*last*]]
+// which lists source lines.
+//
+// See also "breakpoint".
+func ListCommand(args []string) {
+	arg := strings.Join(args[1:], "")
+	var err error
+	switch {
+	case arg == "":
+		err = gub.ListNext()
+	case arg == "-":
+		err = gub.ListPrevious()
+	case strings.Contains(arg, ",") && !strings.HasPrefix(arg, "("):
+		i := strings.LastIndex(arg, ",")
+		err = gub.ListRange(arg[:i], arg[i+1:])
+	default:
+		filename, line, lerr := gub.ListLoc(arg)
+		if lerr != nil {
+			err = lerr
+			break
+		}
+		err = gub.ListAround(filename, line)
+	}
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger logpoint command
+
+package gubcmd
+
+import (
+	"go/parser"
+	"strconv"
+	"strings"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "logpoint"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: LogpointCommand,
+		Help: `logpoint *location* "*format*" [if *expr*]
+
+Set a logpoint: each time *location* is reached, print a message and
+keep going, without stopping in the debugger. *location* is given as
+in the "breakpoint" command.
+
+*format* is a Go string literal. Each {*expr*} in it is replaced by the
+value of Go expression *expr* evaluated in the frame that hits the
+logpoint. Use {{ and }} for literal braces.
+
+Messages are shown along with other debugger output, unless "set
+logfile" has been used to send them to a file.
+
+Examples:
+   logpoint main.fib "fib called with n={n}"
+   logpoint server.go:42 "request {req.URL} from {req.RemoteAddr}"
+
+Conditions and ignore counts apply to logpoints as they do to
+breakpoints; they are deleted and disabled in the same way.
+
+See also "breakpoint", "set logfile", and "info break".
+`,
+		Min_args: 2,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+	gub.AddAlias("dprintf", name)
+}
+
+// LogpointCommand implements the debugger command:
+//    logpoint *location* "*format*" [if *expr*]
+// which sets a location that prints a message instead of stopping.
+//
+// See also "breakpoint", "set logfile", and "info break".
+func LogpointCommand(args []string) {
+	argstr := gub.CmdArgstr
+	i := strings.Index(argstr, "\"")
+	j := closingQuote(argstr, i)
+	if j < 0 {
+		gub.Errmsg("Expecting a quoted format string after the location")
+		return
+	}
+	format, err := strconv.Unquote(argstr[i:j+1])
+	if err != nil {
+		gub.Errmsg("Bad format string %s: %s", argstr[i:j+1], err.Error())
+		return
+	}
+	if err := gub.CheckLogFormat(format); err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	cond := ""
+	if rest := strings.TrimSpace(argstr[j+1:]); rest != "" {
+		if !strings.HasPrefix(rest, "if ") {
+			gub.Errmsg("Expecting \"if\" after the format string, got %s", rest)
+			return
+		}
+		cond = strings.TrimSpace(rest[len("if "):])
+		if _, err := parser.ParseExpr(cond); err != nil {
+			gub.Errmsg("Invalid condition %s: %s", cond, err.Error())
+			return
+		}
+	}
+	locArgs := strings.Fields(argstr[:i])
+	loc, err := gub.ParseBreakLoc(locArgs)
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	bp := addBreakpoint(loc, "Logpoint", locArgs[0], false, cond)
+	if bp != nil { bp.Log = format }
+}
+
+// closingQuote gives the index of the double quote that ends the
+// string literal starting at index i of s, or -1 if there isn't one.
+func closingQuote(s string, i int) int {
+	if i < 0 { return -1 }
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
+	}
+	return -1
+}
@@ -4,21 +4,30 @@ package gubcmd
 
 import (
 	"github.com/rocky/ssa-interp/gub"
-	"github.com/rocky/ssa-interp/interp"
 )
 
 func init() {
 	name := "next"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: NextCommand,
-		Help: `next
+		Help: `next [*count*]
 
 Step one statement ignoring steps into function calls at this level.
 
 Sometimes this is called 'step over'.
+
+With *count*, step over that many statements, stopping only at the
+end. A breakpoint, watchpoint or catchpoint along the way stops
+earlier.
+
+Only the goroutine selected, see "goroutine", is stepped; other
+goroutines run without stopping except at breakpoints.
+
+If we have gone back in the recorded execution history, this goes
+forward over it instead. See also "reverse-next".
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 1,
 	}
 	gub.AddToCategory("running", name)
 	// Down the line we'll have abbrevs
@@ -26,7 +35,17 @@ Sometimes this is called 'step over'.
 }
 
 func NextCommand(args []string) {
-	interp.SetStepOver(gub.TopFrame())
+	count, ok := stepCount(args, "next")
+	if !ok { return }
+	if gub.Replaying() {
+		for ; count > 0 && gub.Replaying(); count-- {
+			gub.HistoryForward(gub.HISTORY_NEXT)
+		}
+		gub.LastCommand = "next " + gub.CmdArgstr
+		return
+	}
+	if cantResume("next") { return }
+	gub.Step(gub.TopFrame(), gub.STEP_OVER, count)
 	gub.Msg("Step over...")
 	gub.LastCommand = "next " + gub.CmdArgstr
 	gub.InCmdLoop = false
@@ -0,0 +1,57 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger breakpoint-by-regular-expression command
+
+package gubcmd
+
+import (
+	"regexp"
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "rbreak"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: RbreakCommand,
+		Help: `rbreak *regexp*
+
+Set a breakpoint on every function whose name matches regular
+expression *regexp*. Names are matched in their package-qualified
+form, e.g. main.foo or (*main.T).Method. Synthetic wrappers and
+built-in external functions are skipped.
+
+Examples:
+   rbreak ^main\.      # every function in package main
+   rbreak Method$      # every function or method ending in Method
+
+See also "breakpoint", "info break", and "delete".
+`,
+
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// RbreakCommand implements the debugger command:
+//    rbreak *regexp*
+// which sets a breakpoint on every function whose name matches *regexp*.
+//
+// See also "breakpoint", "info break", and "delete".
+func RbreakCommand(args []string) {
+	re, err := regexp.Compile(gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg("Invalid regular expression %s: %s", gub.CmdArgstr,
+			err.Error())
+		return
+	}
+	fns := gub.FunctionsMatching(re)
+	if len(fns) == 0 {
+		gub.Errmsg("No functions match %s", gub.CmdArgstr)
+		return
+	}
+	for _, fn := range fns {
+		loc := &gub.BreakLoc{Fn: fn, Pos: fn.Pos(), EndP: fn.EndP(),
+			ByName: true}
+		addBreakpoint(loc, "Breakpoint", fn.String(), false, "")
+	}
+}
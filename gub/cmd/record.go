@@ -0,0 +1,90 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger record command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	name := "record"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: RecordCommand,
+		Help: `record [on|off]
+
+Start or stop recording the execution history of the program. While
+recording, "reverse-step", "reverse-next" and "reverse-continue" run
+the program backwards, undoing changes to variables, fields, elements
+and map entries.
+
+After going back, "step", "next" and "continue" go forward again over
+the recorded history until they reach the present; execution then
+resumes as usual.
+
+Changes made by built-in functions like copy() and append(), as well as
+input, output and channel operations, are not undone.
+
+Recording uses memory. When the history would use more than the limit
+given by "set recordlimit", the oldest part of it is forgotten.
+"record off" throws the history away.
+
+See also "info record", "reverse-step", and "set recordlimit".
+`,
+		Min_args: 0,
+		Max_args: 1,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rec", name)
+}
+
+// RecordCommand implements the debugger command:
+//    record [on|off]
+// which starts or stops recording the execution history.
+//
+// See also "info record", "reverse-step", and "set recordlimit".
+func RecordCommand(args []string) {
+	onoff := "on"
+	if len(args) == 2 {
+		onoff = args[1]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		if interp.Recording() {
+			gub.Errmsg("Already recording")
+			return
+		}
+		interp.StartRecording()
+		gub.Msg("Recording execution history")
+	case ONOFF_OFF:
+		if !interp.Recording() {
+			gub.Errmsg("Not recording")
+			return
+		}
+		gub.HistoryPresent()
+		interp.StopRecording()
+		gub.Msg("Execution history discarded")
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
+
+// haveHistory reports whether there is execution history to go over,
+// complaining if not.
+func haveHistory() bool {
+	if !interp.Recording() {
+		gub.Errmsg("Not recording execution history. Use \"record\" first.")
+		return false
+	}
+	return true
+}
+
+// notWhileReplaying complains and returns true if we have gone back
+// in the execution history, where command name can't be used.
+func notWhileReplaying(name string) bool {
+	if !gub.Replaying() { return false }
+	gub.Errmsg("Can't use \"%s\" while going over recorded history; " +
+		"\"continue\" goes forward to the present.", name)
+	return true
+}
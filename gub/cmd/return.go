@@ -0,0 +1,50 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger return command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "return"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReturnCommand,
+		Help: `return [*expr* [, *expr*...]]
+
+Make the selected frame return right away, with the values of the
+expressions as its results. Without expressions, named results keep
+their values and unnamed ones are the zero values of their types. For
+example:
+
+   return
+   return 5
+   return n+1, nil
+
+As with a return statement, the values are assigned to the named
+results first. Deferred calls of the frame then run, as do those of
+the frames it has called, which return too; changes that deferred
+calls make to named results are reflected in what is returned.
+
+The debugger stops again as the frame returns.
+
+See also "finish" and "jump".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("running", name)
+}
+
+// ReturnCommand implements the debugger command:
+//    return [*expr* [, *expr*...]]
+// which makes the selected frame return early.
+func ReturnCommand(args []string) {
+	if notWhileReplaying("return") { return }
+	if cantResume("return") { return }
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	if err := gub.ReturnFromFrame(gub.CurFrame(), gub.CmdArgstr); err != nil {
+		gub.Errmsg("%s", err.Error())
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger reverse-continue command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "reverse-continue"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReverseContinueCommand,
+		Help: `reverse-continue
+
+Run the program backwards until a breakpoint would have stopped it, or
+until the start of the recorded history.
+
+Execution history must be recorded; see "record".
+
+See also "continue", "reverse-step", and "reverse-next".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rc", name)
+}
+
+// ReverseContinueCommand implements the debugger command: reverse-continue
+//
+// See also "record".
+func ReverseContinueCommand(args []string) {
+	if !haveHistory() { return }
+	gub.HistoryBackward(gub.HISTORY_CONTINUE)
+	gub.LastCommand = "reverse-continue"
+}
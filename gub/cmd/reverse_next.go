@@ -0,0 +1,36 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger reverse-next command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "reverse-next"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReverseNextCommand,
+		Help: `reverse-next
+
+Run the program backwards to the previous statement of the current
+function, passing over any calls it made. At the start of a function
+this goes back to the caller.
+
+Execution history must be recorded; see "record".
+
+See also "next", "reverse-step", and "reverse-continue".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rn", name)
+}
+
+// ReverseNextCommand implements the debugger command: reverse-next
+//
+// See also "record".
+func ReverseNextCommand(args []string) {
+	if !haveHistory() { return }
+	gub.HistoryBackward(gub.HISTORY_NEXT)
+	gub.LastCommand = "reverse-next"
+}
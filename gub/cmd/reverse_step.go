@@ -0,0 +1,35 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger reverse-step command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "reverse-step"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: ReverseStepCommand,
+		Help: `reverse-step
+
+Run the program backwards to the previous statement that ran in the
+current goroutine, going into functions that were called.
+
+Execution history must be recorded; see "record".
+
+See also "step", "reverse-next", and "reverse-continue".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("rs", name)
+}
+
+// ReverseStepCommand implements the debugger command: reverse-step
+//
+// See also "record".
+func ReverseStepCommand(args []string) {
+	if !haveHistory() { return }
+	gub.HistoryBackward(gub.HISTORY_STEP)
+	gub.LastCommand = "reverse-step"
+}
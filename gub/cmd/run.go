@@ -5,31 +5,113 @@
 package gubcmd
 
 import (
-	"github.com/rocky/ssa-interp/gub"
+	"io/ioutil"
 	"os"
+	"strings"
 	"syscall"
+
+	"github.com/rocky/ssa-interp/gub"
 )
 
 func init() {
 	name := "run"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: RunCommand,
-		Help: `run
+		Help: `run [*checkpoint*]
+
+Restarts the program from the beginning.
 
-Terminates program. If an exit code is given, that is the exit code
-for the program. Zero (normal termination) is used if no
-termintation code.
+Breakpoints and logpoints, along with their conditions, ignore counts,
+enabled states and command lists, are carried over into the restarted
+program. Watchpoints are not.
+
+Given the number of a checkpoint, the program instead goes back to
+that checkpoint and resumes there; see "checkpoint".
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 1,
 	}
 	gub.AddToCategory("running", name)
 	gub.AddAlias("R", name)
 	gub.AddAlias("restart", name)
 }
 
+// restartArgs gives the arguments to restart with, arranging for gub
+// to read its commands from cmdfile first and then remove it.
+func restartArgs(cmdfile string) []string {
+	cmdfileOpt := "-rmcmdfile -cmdfile=" + cmdfile
+	args := make([]string, 0, len(gub.RESTART_ARGS)+1)
+	found := false
+	for j := 0; j < len(gub.RESTART_ARGS); j++ {
+		arg := gub.RESTART_ARGS[j]
+		if arg == "--" || found {
+			args = append(args, arg)
+			continue
+		}
+		if (arg == "-gub" || arg == "--gub") && j+1 < len(gub.RESTART_ARGS) {
+			// The options are in the next argument.
+			j++
+			args = append(args, arg,
+				gubOptsWithCmdfile(gub.RESTART_ARGS[j], cmdfileOpt))
+			found = true
+			continue
+		}
+		for _, prefix := range []string{"-gub=", "--gub="} {
+			if !strings.HasPrefix(arg, prefix) { continue }
+			arg = prefix + gubOptsWithCmdfile(arg[len(prefix):], cmdfileOpt)
+			found = true
+		}
+		args = append(args, arg)
+	}
+	if !found {
+		args = append([]string{args[0], "-gub=" + cmdfileOpt}, args[1:]...)
+	}
+	return args
+}
+
+// gubOptsWithCmdfile replaces any command file in gub options opts
+// with cmdfileOpt.
+func gubOptsWithCmdfile(opts string, cmdfileOpt string) string {
+	kept := make([]string, 0)
+	for _, opt := range strings.Split(opts, " ") {
+		if opt != "" && opt != "-rmcmdfile" &&
+			!strings.HasPrefix(opt, "-cmdfile=") {
+			kept = append(kept, opt)
+		}
+	}
+	return strings.Join(append(kept, cmdfileOpt), " ")
+}
+
 func RunCommand(args []string) {
+	if len(args) == 2 {
+		id, err := gub.GetInt(args[1], "checkpoint number", 1, len(gub.Checkpoints))
+		if err != nil { return }
+		if err := gub.CheckpointRestart(id); err != nil {
+			gub.Errmsg("Can't restart checkpoint %d: %s", id, err.Error())
+		}
+		return
+	}
+	restart := gub.RESTART_ARGS
+	cmdfile := ""
+	if file, err := ioutil.TempFile("", "gub-restart"); err != nil {
+		gub.Errmsg("Can't save breakpoints for restart: %s", err.Error())
+	} else {
+		cmdfile = file.Name()
+		_, err := writeBreakpoints(file)
+		if cerr := file.Close(); err == nil { err = cerr }
+		if err != nil {
+			gub.Errmsg("Can't save breakpoints for restart: %s", err.Error())
+			os.Remove(cmdfile)
+			cmdfile = ""
+		} else {
+			// The restarted gub removes the file once it has it open.
+			restart = restartArgs(cmdfile)
+		}
+	}
 	ShowArgsSubcmd(args)
 	gub.Msg("gub: restarting...")
-	syscall.Exec(gub.RESTART_ARGS[0], gub.RESTART_ARGS[0:], os.Environ());
+	err := syscall.Exec(restart[0], restart[0:], os.Environ());
+	// We only get here if the restart failed.
+	if cmdfile != "" { os.Remove(cmdfile) }
+	gub.Errmsg("Can't restart: %s", err.Error())
 }
@@ -0,0 +1,42 @@
+package gubcmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+type restartDatum struct {
+	args []string // arguments gub was started with
+	want []string // arguments to restart with
+}
+
+var restartData = []restartDatum {
+	{args: []string{"tortoise", "-run", "prog.go"},
+		want: []string{"tortoise", "-gub=-rmcmdfile -cmdfile=bp.cmd",
+			"-run", "prog.go"}},
+	{args: []string{"tortoise", "-gub=-cmdfile=old.cmd -trace", "prog.go"},
+		want: []string{"tortoise", "-gub=-trace -rmcmdfile -cmdfile=bp.cmd",
+			"prog.go"}},
+	{args: []string{"tortoise", "--gub", "-cmdfile=old.cmd", "prog.go"},
+		want: []string{"tortoise", "--gub", "-rmcmdfile -cmdfile=bp.cmd",
+			"prog.go"}},
+	{args: []string{"tortoise", "-gub", "-trace", "prog.go", "--", "-gub", "x"},
+		want: []string{"tortoise", "-gub", "-trace -rmcmdfile -cmdfile=bp.cmd",
+			"prog.go", "--", "-gub", "x"}},
+}
+
+// Checks that restarting replaces gub's command file whichever way
+// its options were given.
+func TestRestartArgs(t *testing.T) {
+	save := gub.RESTART_ARGS
+	defer func() { gub.RESTART_ARGS = save }()
+	for _, test := range restartData {
+		gub.RESTART_ARGS = test.args
+		if got := restartArgs("bp.cmd"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("restartArgs with %q: got %q, want %q",
+				test.args, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2015 Rocky Bernstein.
+
+// save command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "save"
+	gub.Cmds[name] = &gub.CmdInfo{
+		SubcmdMgr: &gub.SubcmdMgr{
+			Name   : name,
+			Subcmds: make(gub.SubcmdMap),
+		},
+		Fn: SaveCommand,
+		Help: `Save parts of the debugger state to a file of debugger commands.
+
+Type "save" for a list of "save" subcommands and what they do.
+Type "help save *" for just a list of "save" subcommands.
+
+See also "source".`,
+		Min_args: 0,
+		Max_args: 2,
+	}
+	gub.AddToCategory("support", name)
+}
+
+func SaveCommand(args []string) {
+	gub.SubcmdMgrCommand(args)
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Rocky Bernstein.
+
+// save breakpoints - write breakpoints as debugger commands
+
+package gubcmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "save"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SaveBreakpointsSubcmd,
+		Help: `save breakpoints *file*
+
+Write debugger commands to *file* that set up the current breakpoints
+and logpoints again: their locations, conditions, ignore counts,
+enabled states and command lists. Use "source *file*" to read them
+back in. Watchpoints are not saved.`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "save breakpoints to a command file",
+		Name: "breakpoints",
+	})
+}
+
+func SaveBreakpointsSubcmd(args []string) {
+	name := args[2]
+	file, err := os.Create(name)
+	if err != nil {
+		gub.Errmsg("Can't create %s: %s", name, err.Error())
+		return
+	}
+	count, err := writeBreakpoints(file)
+	if cerr := file.Close(); err == nil { err = cerr }
+	if err != nil {
+		gub.Errmsg("Error writing %s: %s", name, err.Error())
+		return
+	}
+	switch count {
+	case 0:
+		gub.Msg("No breakpoints to save; %s is empty.", name)
+	case 1:
+		gub.Msg("Saved 1 breakpoint to %s.", name)
+	default:
+		gub.Msg("Saved %d breakpoints to %s.", count, name)
+	}
+}
+
+// writeBreakpoints writes the debugger commands that set up the
+// current breakpoints to w. It returns how many breakpoints were
+// written.
+func writeBreakpoints(w io.Writer) (int, error) {
+	out := bufio.NewWriter(w)
+	count := 0
+	for _, bp := range gub.Breakpoints {
+		cmds := gub.BreakpointCommands(bp)
+		if len(cmds) == 0 { continue }
+		count++
+		for _, cmd := range cmds {
+			if _, err := out.WriteString(cmd + "\n"); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, out.Flush()
+}
@@ -20,7 +20,7 @@ func init() {
 
 Type "set" for a list of "set" subcommands and what they do.`,
 		Min_args: 0,
-		Max_args: 3,
+		Max_args: -1,
 	}
 	gub.AddToCategory("support", name)
 }
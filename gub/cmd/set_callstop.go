@@ -0,0 +1,44 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set callstop - stop in functions called from the debugger?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetCallstopSubcmd,
+		Help: `set callstop [on|off]
+
+Sets whether breakpoints, watchpoints and catchpoints stop the program
+inside functions called from the debugger by "call" or in
+expressions. When off, the default, they are ignored there.
+
+See also "call".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "stop in functions called from the debugger",
+		Name: "callstop",
+	})
+}
+
+func SetCallstopSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.CallStop = true
+		gub.Msg("Breakpoints stop in functions called from the debugger")
+	case ONOFF_OFF:
+		gub.CallStop = false
+		gub.Msg("Breakpoints are ignored in functions called from the debugger")
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
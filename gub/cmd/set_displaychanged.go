@@ -0,0 +1,44 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set displaychanged - highlight displayed values that changed?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetDisplayChangedSubcmd,
+		Help: `set displaychanged [on|off]
+
+Sets whether values shown by "display" that changed since the last
+stop are highlighted. Without highlighting, "(changed)" is added after
+them.
+
+See also "display".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "highlight displayed values that changed",
+		Name: "displaychanged",
+	})
+}
+
+func SetDisplayChangedSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.DisplayChanged = true
+		gub.Msg("Displayed values that changed are highlighted")
+	case ONOFF_OFF:
+		gub.DisplayChanged = false
+		gub.Msg("Displayed values that changed are not highlighted")
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set logfile - where logpoint messages go
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetLogfileSubcmd,
+		Help: `set logfile [*file*]
+
+Append messages from logpoints to *file*. Without *file*, messages go
+back to being shown with the rest of the debugger output.
+
+See also "logpoint" and "show logfile".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "file for logpoint output",
+		Name: "logfile",
+	})
+}
+
+func SetLogfileSubcmd(args []string) {
+	name := ""
+	if len(args) == 3 {
+		name = args[2]
+	}
+	if err := gub.SetLogFile(name); err != nil {
+		gub.Errmsg("Can't open log file %s: %s", name, err.Error())
+		return
+	}
+	if name == "" {
+		gub.Msg("Logpoint output goes to the debugger output")
+	} else {
+		gub.Msg("Logpoint output goes to %s", name)
+	}
+}
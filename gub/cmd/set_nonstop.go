@@ -0,0 +1,52 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set nonstop - let other goroutines run while stopped?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetNonStopSubcmd,
+		Help: `set nonstop [on|off]
+
+Sets whether goroutines other than the one stopped in keep running
+while the debugger has the program stopped.
+
+When off, the default, all goroutines stop: each waits at its next
+instruction until the program is continued or stepped, and then they
+all resume together. Shared state can be inspected without it
+changing underneath. A goroutine blocked on a channel, or in a
+function outside of the interpreter, stops when that returns.
+
+When on, other goroutines keep running until they too reach a
+breakpoint or other stop, and then they wait for the debugger.
+
+See also "goroutine".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "let other goroutines run while stopped",
+		Name: "nonstop",
+	})
+}
+
+func SetNonStopSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.NonStop = true
+		gub.Msg("Other goroutines run while the program is stopped")
+	case ONOFF_OFF:
+		gub.NonStop = false
+		gub.Msg("All goroutines stop when the program stops")
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set recordlimit - memory budget for execution history
+
+package gubcmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetRecordlimitSubcmd,
+		Help: `set recordlimit *size*
+
+Set the most memory the recorded execution history may use. *size* is
+a number of bytes, optionally followed by k or m for kilobytes or
+megabytes. When the history would use more, its oldest part is
+forgotten. If we have gone back in the history, that waits until we
+are back at the present.
+
+See also "record" and "info record".`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "memory limit for execution history",
+		Name: "recordlimit",
+	})
+}
+
+// parseSize parses a size in bytes with an optional k or m suffix.
+func parseSize(s string) (int, error) {
+	mult := 1
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "k":
+		mult = 1024
+	case "m":
+		mult = 1024 * 1024
+	}
+	if mult != 1 { s = s[:len(s)-1] }
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expecting a positive size, got %s", s)
+	}
+	return n * mult, nil
+}
+
+// formatSize shows a size in bytes in the largest unit that fits.
+func formatSize(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1fK", float64(n)/1024)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+func SetRecordlimitSubcmd(args []string) {
+	size, err := parseSize(args[2])
+	if err != nil {
+		gub.Errmsg(err.Error())
+		return
+	}
+	interp.SetHistoryBudget(size)
+	gub.Msg("Execution history limited to %s", formatSize(size))
+}
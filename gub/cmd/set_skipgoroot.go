@@ -0,0 +1,44 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set skipgoroot - skip GOROOT packages when stepping?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetSkipGorootSubcmd,
+		Help: `set skipgoroot [on|off]
+
+Sets whether "step" steps over calls of functions in packages of the
+Go distribution, such as fmt and reflect, rather than into them. It is
+on by default.
+
+See also "skip".`,
+		Min_args: 0,
+		Max_args: 1,
+		Short_help: "skip GOROOT packages when stepping",
+		Name: "skipgoroot",
+	})
+}
+
+func SetSkipGorootSubcmd(args []string) {
+	onoff := "on"
+	if len(args) == 3 {
+		onoff = args[2]
+	}
+	switch ParseOnOff(onoff) {
+	case ONOFF_ON:
+		gub.SkipGoroot = true
+		gub.Msg("Stepping skips packages in GOROOT")
+	case ONOFF_OFF:
+		gub.SkipGoroot = false
+		gub.Msg("Stepping goes into packages in GOROOT")
+	case ONOFF_UNKNOWN:
+		gub.Msg("Expecting 'on' or 'off', got '%s'; nothing done", onoff)
+	}
+}
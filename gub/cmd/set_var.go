@@ -0,0 +1,64 @@
+// Copyright 2015 Rocky Bernstein.
+
+// set var - assign to a variable of the program
+
+package gubcmd
+
+import (
+	"strings"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "set"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SetVarSubcmd,
+		Help: `set var *lvalue* = *expr*
+
+Assign the value of Go expression *expr* to *lvalue* in the selected
+frame. *lvalue* can be a local or package variable, a field of a
+struct, an element of a slice or array, or an entry of a map, or a
+pointer dereference of any of these. For example:
+
+   set var x = 5
+   set var p.name = "gub"
+   set var a[i+1] = a[i]
+   set var m["key"] = 2.5
+   set var *q = nil
+
+The value must have the type of *lvalue*. Numbers are converted to
+the type of *lvalue* if they fit, as Go does for constants. Values
+that aren't of a basic type can be assigned from another variable of
+the same type, or can be nil.
+
+Like an assignment made by the program, the assignment goes into the
+execution history when it is being recorded, and a watchpoint on
+*lvalue* reports the change.
+
+See also "eval" and "watch".`,
+		Min_args: 1,
+		Max_args: -1,
+		Short_help: "assign to a variable",
+		Name: "var",
+	})
+}
+
+// SetVarSubcmd implements the debugger command:
+//    set var *lvalue* = *expr*
+// which assigns to a variable of the program.
+func SetVarSubcmd(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	assignment := strings.TrimSpace(gub.CmdArgstr[len(args[1]):])
+	lhs, rhs, err := gub.SplitAssign(assignment)
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	val, err := gub.AssignInFrame(gub.CurFrame(), lhs, rhs)
+	if err != nil {
+		gub.Errmsg("Can't set %s: %s", lhs, err.Error())
+		return
+	}
+	gub.Msg("%s = %s", lhs, val)
+}
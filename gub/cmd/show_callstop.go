@@ -0,0 +1,27 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show callstop - stop in functions called from the debugger?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowCallstopSubcmd,
+		Help: `show callstop
+
+Show whether breakpoints stop in functions called from the debugger`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "stop in functions called from the debugger",
+		Name: "callstop",
+	})
+}
+
+func ShowCallstopSubcmd(args []string) {
+	ShowOnOff(args[1], gub.CallStop)
+}
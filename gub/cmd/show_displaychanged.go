@@ -0,0 +1,27 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show displaychanged - highlight displayed values that changed?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowDisplayChangedSubcmd,
+		Help: `show displaychanged
+
+Show whether displayed values that changed are highlighted`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "highlight displayed values that changed",
+		Name: "displaychanged",
+	})
+}
+
+func ShowDisplayChangedSubcmd(args []string) {
+	ShowOnOff(args[1], gub.DisplayChanged)
+}
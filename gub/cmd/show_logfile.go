@@ -0,0 +1,31 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show logfile - where logpoint messages go
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowLogfileSubcmd,
+		Help: `show logfile
+
+Show where logpoint messages go`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "show file for logpoint output",
+		Name: "logfile",
+	})
+}
+
+func ShowLogfileSubcmd(args []string) {
+	if name := gub.LogFileName(); name != "" {
+		gub.Msg("Logpoint output goes to %s", name)
+	} else {
+		gub.Msg("Logpoint output goes to the debugger output")
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show nonstop - let other goroutines run while stopped?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowNonStopSubcmd,
+		Help: `show nonstop
+
+Show whether other goroutines run while the program is stopped`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "let other goroutines run while stopped",
+		Name: "nonstop",
+	})
+}
+
+func ShowNonStopSubcmd(args []string) {
+	ShowOnOff(args[1], gub.NonStop)
+}
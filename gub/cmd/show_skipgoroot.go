@@ -0,0 +1,27 @@
+// Copyright 2015 Rocky Bernstein.
+
+// show skipgoroot - skip GOROOT packages when stepping?
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "show"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: ShowSkipGorootSubcmd,
+		Help: `show skipgoroot
+
+Show whether stepping skips packages in GOROOT`,
+		Min_args: 0,
+		Max_args: 0,
+		Short_help: "skip GOROOT packages when stepping",
+		Name: "skipgoroot",
+	})
+}
+
+func ShowSkipGorootSubcmd(args []string) {
+	ShowOnOff(args[1], gub.SkipGoroot)
+}
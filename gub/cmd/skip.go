@@ -0,0 +1,47 @@
+// Copyright 2015 Rocky Bernstein.
+
+// skip command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "skip"
+	gub.Cmds[name] = &gub.CmdInfo{
+		SubcmdMgr: &gub.SubcmdMgr{
+			Name   : name,
+			Subcmds: make(gub.SubcmdMap),
+		},
+		Fn: SkipCommand,
+		Help: `Name functions that "step" steps over rather than into.
+
+A call of a skipped function is run as "next" would run it, though
+breakpoints in it, or in what it calls, still stop. Functions of
+packages in GOROOT are skipped unless "set skipgoroot" is off.
+
+Type "skip" for a list of "skip" subcommands and what they do.
+Type "help skip *" for just a list of "skip" subcommands.
+
+See also "info skip" and "step into".`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("running", name)
+}
+
+func SkipCommand(args []string) {
+	gub.SubcmdMgrCommand(args)
+}
+
+// addSkip does the work of the "skip" subcommands that add a skip.
+func addSkip(kind string, pattern string) {
+	skip, err := gub.SkipAdd(kind, pattern)
+	if err != nil {
+		gub.Errmsg("%s", err.Error())
+		return
+	}
+	gub.Msg("Skip %d: stepping skips %s %s", skip.Id, kind, pattern)
+}
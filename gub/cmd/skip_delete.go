@@ -0,0 +1,47 @@
+// Copyright 2015 Rocky Bernstein.
+
+// skip delete - delete skips
+
+package gubcmd
+
+import (
+	"fmt"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "skip"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SkipDeleteSubcmd,
+		Help: `skip delete [*num*...]
+
+Delete the skips with the given numbers. Without an argument, delete
+all skips. This doesn't change whether GOROOT packages are skipped;
+use "set skipgoroot" for that.
+
+See also "info skip".`,
+		Min_args: 0,
+		Max_args: -1,
+		Short_help: "delete skips",
+		Name: "delete",
+	})
+}
+
+func SkipDeleteSubcmd(args []string) {
+	if len(args) == 2 {
+		gub.Skips = nil
+		gub.Msg("Deleted all skips")
+		return
+	}
+	for i := 2; i < len(args); i++ {
+		msg := fmt.Sprintf("skip number for argument %d", i-1)
+		id, err := gub.GetInt(args[i], msg, 1, 0)
+		if err != nil { continue }
+		if gub.SkipDelete(id) {
+			gub.Msg("Deleted skip %d", id)
+		} else {
+			gub.Errmsg("Skip %d doesn't exist", id)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2015 Rocky Bernstein.
+
+// skip file - don't step into functions of matching files
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "skip"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SkipFileSubcmd,
+		Help: `skip file *glob*
+
+Skip the functions of source files matching *glob* when stepping in.
+*glob* is matched against the whole file name and against its last
+element, so "*_string.go" skips the code stringer generates.
+
+See also "skip delete" and "info skip".`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "skip files when stepping",
+		Name: "file",
+	})
+}
+
+func SkipFileSubcmd(args []string) {
+	addSkip("file", args[2])
+}
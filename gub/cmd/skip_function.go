@@ -0,0 +1,30 @@
+// Copyright 2015 Rocky Bernstein.
+
+// skip function - don't step into a function
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "skip"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SkipFunctionSubcmd,
+		Help: `skip function *name*
+
+Skip function *name* when stepping in. *name* is a function name like
+fn, a qualified name like pkg/path.fn, or a method name.
+
+See also "skip delete" and "info skip".`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "skip a function when stepping",
+		Name: "function",
+	})
+}
+
+func SkipFunctionSubcmd(args []string) {
+	addSkip("function", args[2])
+}
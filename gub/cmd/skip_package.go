@@ -0,0 +1,31 @@
+// Copyright 2015 Rocky Bernstein.
+
+// skip package - don't step into functions of a package
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	parent := "skip"
+	gub.AddSubCommand(parent, &gub.SubcmdInfo{
+		Fn: SkipPackageSubcmd,
+		Help: `skip package *path*
+
+Skip the functions of the package with import path *path* when stepping
+in. A path ending in "/..." also skips the packages below it, as for
+the go tool.
+
+See also "skip delete" and "info skip".`,
+		Min_args: 1,
+		Max_args: 1,
+		Short_help: "skip a package when stepping",
+		Name: "package",
+	})
+}
+
+func SkipPackageSubcmd(args []string) {
+	addSkip("package", args[2])
+}
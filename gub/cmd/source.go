@@ -0,0 +1,36 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger source command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "source"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: SourceCommand,
+		Help: `source *file*
+
+Read debugger commands from *file*. The commands are run before any
+more commands are read from the terminal. A command that resumes the
+program, like "continue", leaves the rest of the file to be read the
+next time the program stops.
+
+See also "save breakpoints".
+`,
+		Min_args: 1,
+		Max_args: 1,
+	}
+	gub.AddToCategory("support", name)
+}
+
+// SourceCommand implements the debugger command:
+//    source *file*
+// which reads debugger commands from *file*.
+//
+// See also "save breakpoints".
+func SourceCommand(args []string) {
+	if err := gub.SourceFile(args[1]); err != nil {
+		gub.Errmsg("Can't read %s: %s", args[1], err.Error())
+	}
+}
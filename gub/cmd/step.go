@@ -4,38 +4,133 @@
 package gubcmd
 
 import (
+	"strconv"
+
+	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/gub"
-	"github.com/rocky/ssa-interp/interp"
 )
 
 func init() {
 	name := "step"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: StepCommand,
-		Help: `step
+		Help: `step [*count*]
+step into [*fn*|*num*]
 
 Execute the current statement, stopping at the next event.  Sometimes this
 is called 'step into'.
 
-See also: stepi, continue, finish, and next.
+With *count*, step that many times, stopping only at the end. A
+breakpoint, watchpoint or catchpoint along the way stops earlier.
+
+Only the goroutine selected, see "goroutine", is stepped; other
+goroutines run without stopping except at breakpoints.
+
+When a statement makes several calls, as in f(g(x), h(y)), "step"
+stops in the first one made, here g. "step into *fn*" runs the calls
+before the one to *fn* without stopping and stops at the entry of
+*fn*. A call can also be chosen by its number in the list that "step
+into" without an argument shows, in the order the calls are made.
+
+If we have gone back in the recorded execution history, this steps
+forward over it instead.
+
+See also: stepi, continue, finish, next, and reverse-step.
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 2,
 	}
 	gub.AddToCategory("running", name)
 	// Down the line we'll have abbrevs
 	gub.AddAlias("s", name)
 }
 
-// StepCommand implements the debugger command: step
+// StepCommand implements the debugger command:
+//    step [count]
+//    step into [fn|num]
 //
 // This executes the current statement, stopping at the next event.
 // Sometimes this is called 'step into'.
 //
 // See also: stepi, continue, finish, and next.
 func StepCommand(args []string) {
+	if len(args) > 1 && args[1] == "into" {
+		stepInto(args)
+		return
+	}
+	if len(args) > 2 {
+		gub.Errmsg("Too many arguments; expecting a step count")
+		return
+	}
+	count, ok := stepCount(args, "step")
+	if !ok { return }
+	if gub.Replaying() {
+		for ; count > 0 && gub.Replaying(); count-- {
+			gub.HistoryForward(gub.HISTORY_STEP)
+		}
+		gub.LastCommand = "step " + gub.CmdArgstr
+		return
+	}
+	if cantResume("step") { return }
 	gub.Msg("Stepping...")
-	interp.SetStepIn(gub.CurFrame())
+	gub.Step(gub.CurFrame(), gub.STEP_IN, count)
 	gub.LastCommand = "step " + gub.CmdArgstr
 	gub.InCmdLoop = false
 }
+
+// stepCount gets the repeat count of stepping command name from
+// args. The count is 1 if none is given.
+func stepCount(args []string, name string) (int, bool) {
+	if len(args) < 2 { return 1, true }
+	count, err := gub.GetInt(args[1], name + " count", 1, 0)
+	return count, err == nil
+}
+
+// stepInto does the work of "step into". Without a function or call
+// number, it lists the calls that can be chosen.
+func stepInto(args []string) {
+	if notWhileReplaying("step into") { return }
+	if cantResume("step into") { return }
+	fr := gub.TopFrame()
+	calls := gub.StatementCalls(fr)
+	if len(calls) == 0 {
+		gub.Errmsg("There are no calls to step into here")
+		return
+	}
+	if len(args) == 2 {
+		gub.Section("Calls of this statement, in the order made:")
+		fset := fr.Fset()
+		for k, call := range calls {
+			pos := fset.Position(call.Pos())
+			gub.Msg("%3d  %s  (%s, column %d)", k+1, gub.CallName(call),
+				call.Call.Description(), pos.Column)
+		}
+		return
+	}
+	var chosen *ssa2.Call
+	if n, err := strconv.Atoi(args[2]); err == nil {
+		if n < 1 || n > len(calls) {
+			gub.Errmsg("Expecting a call number from 1 to %d; got %d", len(calls), n)
+			return
+		}
+		chosen = calls[n-1]
+	} else {
+		for _, call := range calls {
+			if gub.CallName(call) != args[2] { continue }
+			if chosen != nil {
+				gub.Errmsg("%s is called more than once here; give the call's number",
+					args[2])
+				return
+			}
+			chosen = call
+		}
+		if chosen == nil {
+			gub.Errmsg("%s isn't called here; \"step into\" lists the calls", args[2])
+			return
+		}
+	}
+	gub.Msg("Stepping into %s...", gub.CallName(chosen))
+	gub.StepInto(fr, chosen)
+	gub.LastCommand = "step"
+	gub.InCmdLoop = false
+}
@@ -4,33 +4,38 @@ package gubcmd
 
 import (
 	"github.com/rocky/ssa-interp/gub"
-	"github.com/rocky/ssa-interp/interp"
 )
 
 func init() {
 	name := "stepi"
 	gub.Cmds[name] = &gub.CmdInfo{
 		Fn: StepInstructionCommand,
-		Help: `stepi
+		Help: `stepi [*count*]
 
 Execute one SSA instrcution and stop.
 
+With *count*, execute that many instructions before stopping.
+
 See also step, and next.
 `,
 		Min_args: 0,
-		Max_args: 0,
+		Max_args: 1,
 	}
 	gub.AddToCategory("running", name)
 	// Down the line we'll have abbrevs
 }
 
 // StepInstructionCommand implements the debugger command:
-//   stepi
+//   stepi [count]
 // which executes one SSA instrcution and stop.
 //
 // See also "step", "next", "continue" and "finish".
 func StepInstructionCommand(args []string) {
+	if notWhileReplaying("stepi") { return }
+	if cantResume("stepi") { return }
+	count, ok := stepCount(args, "stepi")
+	if !ok { return }
 	gub.Msg("Stepping Instruction...")
-	interp.SetStepInstruction(gub.CurFrame())
+	gub.Step(gub.CurFrame(), gub.STEP_INSTRUCTION, count)
 	gub.InCmdLoop = false
 }
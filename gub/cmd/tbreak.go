@@ -0,0 +1,35 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger temporary breakpoint command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "tbreak"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: TbreakCommand,
+		Help: `tbreak *location* [goroutine *n*] [if *expr*]
+
+Set a temporary breakpoint. The arguments are the same as for
+"breakpoint". A temporary breakpoint is deleted after the first time
+it stops the program.
+
+See also "breakpoint", "info break", and "delete".
+`,
+
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// TbreakCommand implements the debugger command:
+//    tbreak *location* [goroutine *n*] [if *expr*]
+// which sets a temporary breakpoint: one that is deleted after it
+// is first hit.
+//
+// See also "breakpoint", "info break", and "delete".
+func TbreakCommand(args []string) {
+	setBreakpoint(args, true)
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger undisplay command
+
+package gubcmd
+
+import (
+	"fmt"
+
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "undisplay"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: UndisplayCommand,
+		Help: `undisplay [*num*...]
+
+Delete the displays with the given numbers. Without an argument,
+delete all displays.
+
+See also "display" and "info display".
+`,
+		Min_args: 0,
+		Max_args: -1,
+	}
+	gub.AddToCategory("data", name)
+}
+
+// UndisplayCommand implements the debugger command:
+//    undisplay [*num*...]
+// which deletes displays.
+//
+// See also "display" and "info display".
+func UndisplayCommand(args []string) {
+	if len(args) == 1 {
+		gub.Displays = nil
+		gub.Msg("Deleted all displays")
+		return
+	}
+	for i := 1; i < len(args); i++ {
+		msg := fmt.Sprintf("display number for argument %d", i)
+		id, err := gub.GetInt(args[i], msg, 1, 0)
+		if err != nil { continue }
+		if gub.DisplayDelete(id) {
+			gub.Msg("Deleted display %d", id)
+		} else {
+			gub.Errmsg("Display %d doesn't exist", id)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger until command
+
+package gubcmd
+
+import (
+	"github.com/rocky/ssa-interp/gub"
+)
+
+func init() {
+	name := "until"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: UntilCommand,
+		Help: `until
+
+Continue until a line greater than the current one is reached in the
+current frame, or the frame returns. Use this at the end of a loop
+body to get out of the loop without stepping through each iteration.
+
+Calls made along the way are stepped over. A breakpoint, watchpoint
+or catchpoint stops earlier.
+
+See also "next", "advance" and "finish".
+`,
+		Min_args: 0,
+		Max_args: 0,
+	}
+	gub.AddToCategory("running", name)
+	gub.AddAlias("u", name)
+}
+
+// UntilCommand implements the debugger command:
+//    until
+// which continues until a line past the current one is reached in
+// the current frame.
+//
+// See also "next" and "advance".
+func UntilCommand(args []string) {
+	if notWhileReplaying("until") { return }
+	if cantResume("until") { return }
+	gub.Msg("Running until a greater line...")
+	gub.Until(gub.TopFrame())
+	gub.LastCommand = "until"
+	gub.InCmdLoop = false
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 Rocky Bernstein.
+// Debugger watchpoint command
+
+package gubcmd
+
+import "github.com/rocky/ssa-interp/gub"
+
+func init() {
+	name := "watch"
+	gub.Cmds[name] = &gub.CmdInfo{
+		Fn: WatchCommand,
+		Help: `watch *expr*
+
+Set a watchpoint on *expr*: stop the program whenever the value stored
+there changes. *expr* can be a local or package variable, a struct
+field like x.f or p.f, a slice or array element like a[i], a map
+entry like m[k], or a pointer dereference like *p. An index or key is
+evaluated once, when the watchpoint is set.
+
+When the watchpoint triggers, the old and new values are shown along
+with the position of the statement that did the write.
+
+A watchpoint on a local variable is deleted when its function
+returns.
+
+Watchpoints are numbered along with breakpoints, so "delete",
+"enable", "disable", "condition", and "ignore" work on them too.
+
+See also "info break".
+`,
+		Min_args: 1,
+		Max_args: -1,
+	}
+	gub.AddToCategory("breakpoints", name)
+}
+
+// WatchCommand implements the debugger command:
+//    watch *expr*
+// which stops the program when the value at *expr* changes.
+//
+// See also "breakpoint", "delete", and "info break".
+func WatchCommand(args []string) {
+	// Don't use args, but gub.CmdArgstr which preserves blanks
+	bp, err := gub.WatchpointNew(gub.CurFrame(), gub.CmdArgstr)
+	if err != nil {
+		gub.Errmsg("Can't watch %s: %s", gub.CmdArgstr, err.Error())
+		return
+	}
+	gub.Msg("Watchpoint %d: %s", bp.Id, bp.Watch.Expr)
+}
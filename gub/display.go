@@ -0,0 +1,146 @@
+// Copyright 2015 Rocky Bernstein.
+// Displays: expressions shown every time the program stops.
+
+package gub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+	"github.com/rocky/ssa-interp/terminal"
+)
+
+// A Display is an expression shown whenever the program stops.
+type Display struct {
+	Id    int            // number shown and given to undisplay
+	Expr  string         // Go expression to show
+	Fn    *ssa2.Function // function whose locals Expr uses; nil if none
+	Scope *ssa2.Scope    // innermost scope of those locals
+	last  string         // value shown at the last stop
+	shown bool           // set once the value has been shown
+}
+
+// Displays holds the displays, in the order they were added.
+var Displays []*Display
+
+var lastDisplayId = 0
+
+// DisplayChanged is set if displayed values that changed since the
+// last stop are highlighted.
+var DisplayChanged bool
+
+// displayScope finds the function and scope of the local variables
+// that expr uses in frame fr. The function is nil if expr uses only
+// package variables and constants.
+func displayScope(fr *interp.Frame, expr ast.Expr) (*ssa2.Function, *ssa2.Scope) {
+	var fn *ssa2.Function
+	var scope *ssa2.Scope
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.SelectorExpr:
+			// Only the left side can name a variable.
+			ast.Inspect(e.X, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if ok { fn, scope = identScope(fr, id.Name, fn, scope) }
+				return true
+			})
+			return false
+		case *ast.Ident:
+			fn, scope = identScope(fr, e.Name, fn, scope)
+		}
+		return true
+	})
+	return fn, scope
+}
+
+// identScope updates fn and scope for name if it is a local variable
+// of frame fr, keeping the innermost scope.
+func identScope(fr *interp.Frame, name string, fn *ssa2.Function,
+	scope *ssa2.Scope) (*ssa2.Function, *ssa2.Scope) {
+	nameVal, _, nameScope := EnvLookup(fr, name, curScope)
+	switch nameVal.(type) {
+	case nil, *ssa2.Global:
+		return fn, scope
+	}
+	if scope == nil || inScope(fr.Fn(), nameScope, scope) {
+		scope = nameScope
+	}
+	return fr.Fn(), scope
+}
+
+// inScope reports whether scope is inner, or is nested inside of it,
+// in function fn.
+func inScope(fn *ssa2.Function, scope, inner *ssa2.Scope) bool {
+	if inner == nil { return true }
+	for s := scope; s != nil; s = ssa2.ParentScope(fn, s) {
+		if s == inner { return true }
+	}
+	return false
+}
+
+// DisplayAdd adds a display of exprStr, whose local variables are
+// those of frame fr.
+func DisplayAdd(fr *interp.Frame, exprStr string) (*Display, error) {
+	expr, err := parser.ParseExpr(exprStr)
+	if err != nil { return nil, err }
+	lastDisplayId++
+	d := &Display{Id: lastDisplayId, Expr: exprStr}
+	if fr != nil { d.Fn, d.Scope = displayScope(fr, expr) }
+	Displays = append(Displays, d)
+	return d, nil
+}
+
+// DisplayDelete deletes display id. It returns false if there is no
+// such display.
+func DisplayDelete(id int) bool {
+	for k, d := range Displays {
+		if d.Id == id {
+			Displays = append(Displays[:k], Displays[k+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// DisplayInScope reports whether the variables display d uses can be
+// seen from frame fr stopped in scope.
+func DisplayInScope(d *Display, fr *interp.Frame, scope *ssa2.Scope) bool {
+	if d.Fn == nil { return true }
+	if fr == nil || fr.Fn() != d.Fn { return false }
+	return inScope(d.Fn, scope, d.Scope)
+}
+
+// DisplayString gives where display d applies, for listing it.
+func DisplayString(d *Display) string {
+	if d.Fn == nil { return d.Expr }
+	return fmt.Sprintf("%s  (in %s)", d.Expr, d.Fn.Name())
+}
+
+// ShowDisplay shows display d in frame fr. A value that changed since
+// it was last shown is marked when DisplayChanged is set.
+func ShowDisplay(fr *interp.Frame, d *Display) {
+	val := logValue(fr, d.Expr)
+	changed := d.shown && val != d.last
+	d.last, d.shown = val, true
+	if DisplayChanged && changed {
+		if *Highlight {
+			val = ansiterm.Colorize("yellow", val)
+		} else {
+			val += "  (changed)"
+		}
+	}
+	Msg("%d: %s = %s", d.Id, d.Expr, val)
+}
+
+// ShowDisplays shows the displays whose variables are in scope at the
+// current stop.
+func ShowDisplays() {
+	for _, d := range Displays {
+		if DisplayInScope(d, curFrame, curScope) {
+			ShowDisplay(curFrame, d)
+		}
+	}
+}
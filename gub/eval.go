@@ -5,7 +5,10 @@ package gub
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+	"github.com/rocky/eval"
+	"github.com/rocky/go-types"
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/interp"
 )
@@ -88,3 +91,107 @@ func GetFunction(name string) *ssa2.Function {
 	}
 	return nil
 }
+
+// EvalExprInFrame evaluates expr via eval against the locals and
+// globals of frame fr. Functions of the program that expr calls are
+// run by the interpreter. Compile errors, evaluation panics, and
+// anything that goes wrong inside eval itself come back as an error
+// rather than getting printed.
+func EvalExprInFrame(fr *interp.Frame, expr string) (results []reflect.Value, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if interp.IsUnwind(x) { panic(x) }
+			results = nil
+			err = fmt.Errorf("internal error evaluating %s: %v", expr, x)
+		}
+	}()
+	env := interp.MakeEnv(eval.MakeSimpleEnv(), program, fr)
+	// eval can't call functions of the program, so we call them
+	// first and have eval use their results.
+	expr, err = bindCalls(fr, expr, env)
+	if err != nil { return nil, err }
+	results, panik, compileErrs := eval.EvalEnv(expr, env)
+	if compileErrs != nil {
+		msgs := make([]string, len(compileErrs))
+		for i, e := range compileErrs {
+			msgs[i] = e.Error()
+		}
+		return nil, fmt.Errorf("%s", strings.Join(msgs, "; "))
+	} else if panik != nil {
+		return nil, panik
+	}
+	return results, nil
+}
+
+// EvalBoolInFrame evaluates expr in frame fr and insists that the
+// result be a single boolean value.
+func EvalBoolInFrame(fr *interp.Frame, expr string) (bool, error) {
+	results, err := EvalExprInFrame(fr, expr)
+	if err != nil {
+		return false, err
+	}
+	if len(results) != 1 || results[0].Kind() != reflect.Bool {
+		return false, fmt.Errorf("%s is not a boolean expression", expr)
+	}
+	return results[0].Bool(), nil
+}
+
+// basicKind2Reflect maps the basic types of the interpreted program
+// to the Go types the interpreter uses to represent them.
+var basicKind2Reflect = map[types.BasicKind]reflect.Type{
+	types.Bool:       reflect.TypeOf(false),
+	types.Int:        reflect.TypeOf(int(0)),
+	types.Int8:       reflect.TypeOf(int8(0)),
+	types.Int16:      reflect.TypeOf(int16(0)),
+	types.Int32:      reflect.TypeOf(int32(0)),
+	types.Int64:      reflect.TypeOf(int64(0)),
+	types.Uint:       reflect.TypeOf(uint(0)),
+	types.Uint8:      reflect.TypeOf(uint8(0)),
+	types.Uint16:     reflect.TypeOf(uint16(0)),
+	types.Uint32:     reflect.TypeOf(uint32(0)),
+	types.Uint64:     reflect.TypeOf(uint64(0)),
+	types.Uintptr:    reflect.TypeOf(uintptr(0)),
+	types.Float32:    reflect.TypeOf(float32(0)),
+	types.Float64:    reflect.TypeOf(float64(0)),
+	types.Complex64:  reflect.TypeOf(complex64(0)),
+	types.Complex128: reflect.TypeOf(complex128(0)),
+	types.String:     reflect.TypeOf(""),
+}
+
+// Reflect2InterpVal converts rv, a value computed by eval, into the
+// interpreter's representation of a value of type typ. Only basic
+// types are handled so far.
+func Reflect2InterpVal(rv reflect.Value, typ types.Type) (interp.Value, error) {
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return nil, fmt.Errorf("can't convert to %s yet", typ)
+	}
+	rtyp := basicKind2Reflect[basic.Kind()]
+	if rtyp == nil {
+		return nil, fmt.Errorf("can't convert to %s", typ)
+	}
+	if !rv.IsValid() || !kindFits(rv.Kind(), basic.Info()) ||
+		!rv.Type().ConvertibleTo(rtyp) {
+		return nil, fmt.Errorf("value isn't convertible to %s", typ)
+	}
+	return rv.Convert(rtyp).Interface(), nil
+}
+
+// kindFits reports whether a value of kind k can stand for a value of
+// a basic type with info: booleans and strings only for themselves,
+// and numbers only for numbers. reflect would also convert an integer
+// to a string holding that rune, which Go assignment doesn't.
+func kindFits(k reflect.Kind, info types.BasicInfo) bool {
+	switch {
+	case info&types.IsBoolean != 0:
+		return k == reflect.Bool
+	case info&types.IsString != 0:
+		return k == reflect.String
+	case info&types.IsInteger != 0:
+		return isIntKind(k) || isFloatKind(k)
+	case info&(types.IsFloat|types.IsComplex) != 0:
+		return isIntKind(k) || isFloatKind(k) ||
+			info&types.IsComplex != 0 && (k == reflect.Complex64 || k == reflect.Complex128)
+	}
+	return false
+}
@@ -4,10 +4,16 @@
 package gub
 
 import (
+	"fmt"
+
 	"github.com/rocky/ssa-interp"
 	"github.com/rocky/ssa-interp/interp"
 )
 
+// stopFrame is the frame the program stopped in. topFrame is the
+// innermost frame of the selected goroutine, which is the goroutine
+// of stopFrame unless "goroutine" has selected another.
+var stopFrame *interp.Frame
 var topFrame *interp.Frame
 var curFrame *interp.Frame
 var curScope *ssa2.Scope
@@ -31,10 +37,12 @@ const MAXSTACKSHOW = 50
 
 func CurFrame() *interp.Frame { return curFrame }
 func TopFrame() *interp.Frame { return topFrame }
+func StopFrame() *interp.Frame { return stopFrame }
 func CurScope() *ssa2.Scope   { return curScope }
 func CurBlock() *ssa2.BasicBlock { return curBlock }
 
 func frameInit(fr *interp.Frame) {
+	stopFrame = fr
 	topFrame = fr
 	curFrame = fr
 	frameIndex = 0
@@ -44,7 +52,7 @@ func frameInit(fr *interp.Frame) {
 		stackSize++
 	}
 	switch TraceEvent  {
-	case ssa2.CALL_RETURN, ssa2.PROGRAM_TERMINATION:
+	case ssa2.CALL_RETURN, ssa2.PROGRAM_TERMINATION, ssa2.GOROUTINE_EXIT:
 		/* These guys are not in a basic block, so curFrame.Scope
            won't work here. . Not sure why fr.Fn() memory crashes either.
            Otherwise, I'd use fr.Fn().Scope
@@ -105,7 +113,7 @@ func AdjustFrame(frameNum int, absolutePos bool) {
 	curFrame = frame
 	frameIndex = frameNum
 	event := ssa2.CALL_ENTER
-	if (0 == frameIndex) {
+	if (0 == frameIndex && topFrame == stopFrame) {
 		event = TraceEvent
 	}
 	printLocInfo(curFrame, nil, event)
@@ -132,7 +140,11 @@ func PrintGoroutine(goNum int, goTops []*interp.GoreState) {
 	}
 	switch fr.Status() {
 	case interp.StRunning:
-		Section("Goroutine %d", goNum)
+		if reason := goTops[goNum].WaitReason(); reason != "" {
+			Section("Goroutine %d [%s]", goNum, reason)
+		} else {
+			Section("Goroutine %d", goNum)
+		}
 		PrintStack(fr, MAXSTACKSHOW)
 	case interp.StComplete:
 		Msg("Goroutine %d completed", goNum)
@@ -140,3 +152,46 @@ func PrintGoroutine(goNum int, goTops []*interp.GoreState) {
 		Msg("Goroutine %d panic", goNum)
 	}
 }
+
+// PrintLeaks lists the goroutines that were still alive when the
+// program's main function returned, with what each is blocked in, its
+// stack and where it was started. They are only found with -leakcheck.
+func PrintLeaks() {
+	i := interp.GetInterpreter()
+	leaks := i.Leaks()
+	if len(leaks) == 0 { return }
+	Section("%d goroutine(s) still running when main.main returned:", len(leaks))
+	goTops := i.GoTops()
+	for _, goNum := range leaks {
+		g := goTops[goNum]
+		reason := g.WaitReason()
+		if reason == "" { reason = "running" }
+		Msg("Goroutine %d [%s], started by %s at %s", goNum, reason,
+			g.StartFn().Name(), program.Fset.Position(g.StartPos()))
+		if fr := i.RunningFrame(goNum); fr != nil {
+			PrintStack(fr, MAXSTACKSHOW)
+		}
+	}
+}
+
+// SelectGoroutine makes the innermost running frame of goroutine
+// goNum the top and current frame, so that frame and stepping
+// commands apply to that goroutine.
+func SelectGoroutine(goNum int) error {
+	fr := interp.GetInterpreter().RunningFrame(goNum)
+	if fr == nil {
+		return fmt.Errorf("goroutine %d isn't running", goNum)
+	}
+	topFrame = fr
+	curFrame = fr
+	frameIndex = 0
+	curBlock = fr.Block()
+	curScope = nil
+	if curBlock != nil { curScope = fr.Scope() }
+	stackSize = 0
+	for f := fr; f != nil; f = f.Caller(0) {
+		stackSize++
+	}
+	printLocInfo(fr, nil, ssa2.CALL_ENTER)
+	return nil
+}
@@ -21,7 +21,12 @@ import (
 var terse     = flag.Bool("terse", true, `abbreviated output`)
 var testing   = flag.Bool("testing", false, `used in testing`)
 var Highlight = flag.Bool("highlight", true, `use syntax highlighting in output`)
-var inputFilename = flag.String("cmdfile", "", `cmdfile *commandfile*.`)
+var inputFilename = flag.String("cmdfile", "", `cmdfile *commandfile*.
+When input is a terminal, reading continues from it after the
+command file has been read.`)
+var removeCmdfile = flag.Bool("rmcmdfile", false, `remove the command
+file once it has been opened. "run" uses this for the file of
+breakpoints it restarts with.`)
 var inputFile *os.File
 var inputReader *bufio.Reader
 var buffer = bytes.NewBuffer(make([]byte, 1024))
@@ -114,13 +119,26 @@ func process_options(options *string) {
 		os.Args = args
 		flag.Parse()
 		if *testing { *Highlight = false }
-		if inputFilename != nil && len(*inputFilename) > 0 {
+		if inputFilename != nil && len(*inputFilename) > 0 &&
+			stdinIsTerminal() {
+			// Read the command file first, then go on to the
+			// terminal.
+			if err := SourceFile(*inputFilename); err != nil {
+				fmt.Println("Error opening debugger command file ",
+					*inputFilename)
+				os.Exit(1)
+			}
+			if *removeCmdfile { os.Remove(*inputFilename) }
+			gnuReadLineSetup()
+			defer gnuReadLineTermination()
+		} else if inputFilename != nil && len(*inputFilename) > 0 {
 			var err error
 			if inputFile, err = os.Open(*inputFilename); err != nil {
 				fmt.Println("Error opening debugger command file ",
 					inputFilename)
 				os.Exit(1)
 			}
+			if *removeCmdfile { os.Remove(*inputFilename) }
 			inputReader = bufio.NewReader(inputFile)
 		} else {
 			gnuReadLineSetup()
@@ -145,5 +163,6 @@ func Install(options *string, restart_args []string, prog *ssa2.Program) {
 	}
 	defer gnuReadLineTermination()
 	interp.SetTraceHook(GubTraceHook)
+	interp.SetStepInSkip(skipFunction)
 	process_options(options)
 }
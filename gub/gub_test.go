@@ -16,6 +16,7 @@ const slash = string(os.PathSeparator)
 type testDatum struct {
 	gofile  string
 	baseName string
+	options  []string // extra options for tortoise, if any
 }
 
 // Note we should order these from simple to more complex
@@ -26,6 +27,31 @@ var testData = []testDatum {
 	{gofile: "gcd",      baseName: "frame"},
 //	{gofile: "expr",     baseName: "eval"},
 	{gofile: "gcdBrkpt", baseName: "runtimeBrkpt"},
+	{gofile: "postmortem", baseName: "postmortem",
+		options: []string{"-postmortem"}},
+	{gofile: "gcd",      baseName: "condbrkpt"},
+	{gofile: "gcd",      baseName: "tbreak"},
+	{gofile: "watch",    baseName: "watch"},
+	{gofile: "method",   baseName: "breakloc"},
+	{gofile: "gcd",      baseName: "commands"},
+	{gofile: "gcd",      baseName: "logpoint"},
+	{gofile: "gcd",      baseName: "savebrkpt"},
+	{gofile: "recover",  baseName: "catch"},
+	{gofile: "gcd",      baseName: "reverse"},
+	{gofile: "watch",    baseName: "checkpoint"},
+	{gofile: "watch",    baseName: "setvar"},
+	{gofile: "gcd",      baseName: "call"},
+	{gofile: "gcd",      baseName: "return"},
+	{gofile: "named",    baseName: "namedret"},
+	{gofile: "watch",    baseName: "display"},
+	{gofile: "gcd",      baseName: "until"},
+	{gofile: "into",     baseName: "stepinto"},
+	{gofile: "into",     baseName: "skip"},
+	{gofile: "gcd",      baseName: "list"},
+	{gofile: "method",   baseName: "infosym"},
+	{gofile: "gorout",   baseName: "goroutine"},
+	{gofile: "gorout",   baseName: "nonstop"},
+	{gofile: "deadlock", baseName: "deadlock"},
 }
 
 // Runs debugger on go program with baseName. Then compares output.
@@ -43,8 +69,9 @@ func run(t *testing.T, test testDatum) bool {
 	}
 
 	os.Setenv("TESTING", "true")
-	got, err  := exec.Command("../cmd/tortoise", "-run", "-interp=S",
-		gubOpt, goFile).Output()
+	args := append([]string{"-run", "-interp=S"}, test.options...)
+	args = append(args, gubOpt, goFile)
+	got, err  := exec.Command("../cmd/tortoise", args...).Output()
 
 	if err != nil {
 		fmt.Printf("%s", got)
@@ -27,26 +27,72 @@ var InCmdLoop bool
 // "eval" need the exact text after the command.
 var CmdArgstr string
 
+// NonStop is set if the other goroutines keep running while the
+// debugger has the program stopped. Otherwise, in all-stop mode, they
+// wait at their next instruction until the program is continued.
+var NonStop bool
+
 // NoBp contains the breakpoint number if we are stopped at and by a breakpoint.
 const NoBp = 0xfffff
 var curBpnum int
 
-func skipEvent(fr *interp.Frame, event ssa2.TraceEvent) bool {
-	curBpnum = NoBp
-	if event == ssa2.BREAKPOINT {
-		bps := BreakpointFindByPos(fr.StartP())
-		for _, bpnum := range bps {
-			bp := Breakpoints[bpnum]
-			if !bp.Enabled { continue }
-			// FIXME: check things like the condition
-			curBpnum = bpnum
-			bp.Hits ++
-			break
+// atBreakpoint reports whether we got to instr because of a
+// breakpoint, either a function breakpoint or one set on a statement
+// Trace instruction.
+func atBreakpoint(instr *ssa2.Instruction, event ssa2.TraceEvent) bool {
+	if event == ssa2.BREAKPOINT { return true }
+	if instr != nil {
+		if trace, ok := (*instr).(*ssa2.Trace); ok {
+			return trace.Breakpoint
 		}
 	}
 	return false
 }
 
+// skipEvent decides whether the debugger should ignore this
+// event. It sets curBpnum when a breakpoint is responsible for the
+// stop.
+func skipEvent(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) bool {
+	curBpnum = NoBp
+	if restarting {
+		// Stopping where a checkpoint was taken.
+		restarting = false
+		return false
+	}
+	if isCatchEvent(event) { return !catchTriggered(fr, event) }
+	if !atBreakpoint(instr, event) { return false }
+	bps := BreakpointFindByPos(fr.StartP())
+	for _, bpnum := range bps {
+		bp := Breakpoints[bpnum]
+		if !bp.Enabled { continue }
+		if bp.GoNum >= 0 && fr.GoNum() != bp.GoNum { continue }
+		if !BreakpointCondTrue(bp, fr) { continue }
+		bp.Hits ++
+		if bp.Ignore > 0 {
+			bp.Ignore--
+			continue
+		}
+		if bp.Log != "" {
+			// Logpoints print and never stop.
+			logpointPrint(bp, fr)
+			continue
+		}
+		curBpnum = bpnum
+		if bp.Temp {
+			// One-time breakpoint. curBpnum still refers to it for
+			// the rest of this stop.
+			BreakpointDelete(bpnum)
+		}
+		break
+	}
+	if curBpnum != NoBp { return false }
+	if advanceReached(fr, instr, event) { return false }
+	// No breakpoint triggered. The interpreter reports BREAKPOINT
+	// only when we weren't stepping anyway, except at function entry
+	// where we may be stepping in.
+	return event == ssa2.BREAKPOINT && interp.Tracing(fr) != interp.TRACE_STEP_IN
+}
+
 // computePrompt computes the gub read prompt. It has the command
 // count and a goroutine number if we aren't in the main goroutine.
 func computePrompt() string {
@@ -70,6 +116,7 @@ var Instr *ssa2.Instruction
 func runCommand(name string, args []string) {
 	defer func() {
 		if x := recover(); x != nil {
+			if interp.IsUnwind(x) { panic(x) }
 			Errmsg("Internal error in running command %s", name)
 			debug.PrintStack()
 		}
@@ -81,15 +128,74 @@ func runCommand(name string, args []string) {
 	}
 }
 
+// RunLine runs the single debugger command in line. It returns false
+// if the command is unknown.
+func RunLine(line string) bool {
+	args := strings.Split(line, " ")
+	name := args[0]
+	CmdArgstr = strings.TrimLeft(line[len(name):], " ")
+	if newname := LookupCmd(name); newname != "" {
+		name = newname
+	}
+	if Cmds[name] == nil { return false }
+	runCommand(name, args)
+	return true
+}
+
+// stoppedBy gives the breakpoint or watchpoint that caused the
+// current stop, or nil if we didn't stop because of one.
+func stoppedBy(event ssa2.TraceEvent) *Breakpoint {
+	if event == ssa2.WATCHPOINT { return curWatch }
+	if curBpnum != NoBp { return Breakpoints[curBpnum] }
+	return nil
+}
+
+// isSilent reports whether the command list of bp starts with
+// "silent", so that the stop location isn't shown.
+func isSilent(bp *Breakpoint) bool {
+	return bp != nil && len(bp.Commands) > 0 && bp.Commands[0] == "silent"
+}
+
+// runBreakpointCommands runs the command list attached to bp. It
+// returns true if one of the commands resumed execution, in which
+// case we don't enter the command loop.
+func runBreakpointCommands(bp *Breakpoint) bool {
+	for _, line := range bp.Commands {
+		if line == "silent" || len(line) == 0 || line[0] == '#' { continue }
+		if !RunLine(line) {
+			Errmsg("Unknown command %s in commands for breakpoint %d",
+				line, bp.Id)
+			continue
+		}
+		if !InCmdLoop { return true }
+	}
+	return false
+}
+
 var FirstTime bool = true
 
 // GubTraceHook is the callback hook from interpreter. It contains
 // top-level statement breakout.
 func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) {
 	if !fr.I().TraceEventMask[event] { return }
-	gubLock.Lock()
-    defer gubLock.Unlock()
-	if skipEvent(fr, event) { return }
+	if inDebuggerCall(fr) {
+		// The command that made the call already holds gubLock.
+		if !CallStop || !callStopEvent(instr, event) { return }
+	} else {
+		// Another goroutine may be stopped in the debugger and
+		// waiting for us to stop too.
+		interp.Park(fr)
+		gubLock.Lock()
+		interp.Unpark(fr)
+		defer gubLock.Unlock()
+	}
+	if skipEvent(fr, instr, event) { return }
+	if otherGoroutineStep(fr, event) { return }
+	if keepStepping(fr, event) { return }
+	if !NonStop && !inDebuggerCall(fr) {
+		interp.StopTheWorld(fr.GoNum())
+		defer interp.StartTheWorld()
+	}
 	TraceEvent = event
 	frameInit(fr)
 	if instr == nil && event != ssa2.PROGRAM_TERMINATION {
@@ -97,7 +203,9 @@ func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEve
 	}
 	Instr = instr
 
-	if event == ssa2.BREAKPOINT && Breakpoints[curBpnum].Kind == "Function" {
+	bp := stoppedBy(event)
+	if event == ssa2.BREAKPOINT &&
+		(curBpnum == NoBp || Breakpoints[curBpnum].Kind == "Function") {
 		event = ssa2.CALL_ENTER
 	}
 
@@ -105,16 +213,21 @@ func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEve
 		IntroText()
 		FirstTime = false
 	}
-	printLocInfo(topFrame, instr, event)
+	if !isSilent(bp) {
+		printLocInfo(topFrame, instr, event)
+	}
+	if inDebuggerCall(fr) {
+		Msg("Stopped in a function called from the debugger; " +
+			"\"continue\" finishes the call.")
+	}
+
+	InCmdLoop = true
+	if bp != nil && runBreakpointCommands(bp) { return }
 
 	line := ""
 	var err error
-	for InCmdLoop = true; err == nil && InCmdLoop; cmdCount++ {
-		if inputReader != nil {
-			line, err = inputReader.ReadString('\n')
-		} else {
-			line, err = gnureadline.Readline(computePrompt(), true)
-		}
+	for ; err == nil && InCmdLoop; cmdCount++ {
+		line, err = ReadLine(computePrompt())
         if err != nil {
             break
         }
@@ -136,18 +249,8 @@ func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEve
 			continue
 		}
 
-		name := args[0]
-		CmdArgstr = strings.TrimLeft(line[len(name):], " ")
-		if newname := LookupCmd(name); newname != "" {
-			name = newname
-		}
-		cmd := Cmds[name];
 		LastCommand = ""
-
-		if cmd != nil {
-			runCommand(name, args)
-			continue
-		}
+		if RunLine(line) { continue }
 
 		if len(args) > 0 {
 			if !WhatisName(args[0]) {
@@ -155,7 +258,10 @@ func GubTraceHook(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEve
 			}
 		} else {
 			gnureadline.RemoveHistory(gnureadline.HistoryLength()-1)
-			Errmsg("Unknown command %s\n", cmd)
+			Errmsg("Unknown command %s\n", line)
 		}
 	}
+	// On end of input we may still be back in the recorded history;
+	// the program must go on from the present.
+	if Replaying() { HistoryPresent() }
 }
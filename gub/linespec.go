@@ -0,0 +1,293 @@
+// Copyright 2015 Rocky Bernstein.
+// Parsing breakpoint locations and resolving them to places we can stop.
+
+package gub
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+	"github.com/rocky/ssa-interp/ssautil"
+)
+
+// A BreakLoc is a place the debugger can stop at: either the entry to
+// a function or a statement boundary that has a Trace instruction.
+type BreakLoc struct {
+	Fn     *ssa2.Function // set for a function entry
+	Trace  *ssa2.Trace    // set for a statement boundary
+	Pos    token.Pos
+	EndP   token.Pos
+	ByName bool           // set if the location was given as a function name
+}
+
+// Position gives the source position of loc.
+func (loc *BreakLoc) Position() token.Position {
+	return program.Fset.Position(loc.Pos)
+}
+
+// methodExprRE matches method expressions like (*T).Method,
+// pkg.(T).Method or pkg/path.(*T).Method.
+var methodExprRE = regexp.MustCompile(`^(?:(.+)\.)?\((\*?)(\w+)\)\.(\w+)$`)
+
+// methodNameRE matches methods the way ssa2.Function.String() shows
+// them, e.g. (*pkg/path.T).Method.
+var methodNameRE = regexp.MustCompile(`^\((\*?)(.+)\.(\w+)\)\.(\w+)$`)
+
+// PackageByPathOrName finds a package given either its import path or
+// its name.
+func PackageByPathOrName(name string) *ssa2.Package {
+	if pkg := program.PackagesByPath[name]; pkg != nil {
+		return pkg
+	}
+	return program.PackagesByName[name]
+}
+
+// lookupMethod finds method methodName of the type named typeName in
+// package pkg, or of a pointer to that type if ptr is set.
+func lookupMethod(pkg *ssa2.Package, ptr bool, typeName string,
+	methodName string) (fn *ssa2.Function, err error) {
+	t := pkg.Type(typeName)
+	if t == nil {
+		return nil, fmt.Errorf("%s is not a type in package %s",
+			typeName, pkg.Object.Path())
+	}
+	typ := t.Type()
+	if ptr { typ = types.NewPointer(typ) }
+	recv := typeName
+	if ptr { recv = "*" + typeName }
+	// LookupMethod panics when the method isn't in the method set.
+	defer func() {
+		if x := recover(); x != nil {
+			fn = nil
+			err = fmt.Errorf("(%s).%s is not a method", recv, methodName)
+		}
+	}()
+	fn = program.LookupMethod(typ, pkg.Object, methodName)
+	if fn == nil {
+		return nil, fmt.Errorf("(%s).%s is abstract", recv, methodName)
+	}
+	return fn, nil
+}
+
+// LookupFunction finds a function given as fn, pkg.fn, pkg/path.fn,
+// T.Method, (*T).Method, any of the method forms prefixed with a
+// package name or import path, or (*pkg/path.T).Method. Names without a package are looked up
+// in the package of the current frame.
+func LookupFunction(name string) (*ssa2.Function, error) {
+	pkg := curPackage()
+	noPkg := fmt.Errorf("no current package to look up %s in", name)
+	if m := methodExprRE.FindStringSubmatch(name); m != nil {
+		if m[1] != "" {
+			if pkg = PackageByPathOrName(m[1]); pkg == nil {
+				return nil, fmt.Errorf("can't find package %s", m[1])
+			}
+		}
+		if pkg == nil { return nil, noPkg }
+		return lookupMethod(pkg, m[2] == "*", m[3], m[4])
+	}
+	if m := methodNameRE.FindStringSubmatch(name); m != nil {
+		if pkg = PackageByPathOrName(m[2]); pkg == nil {
+			return nil, fmt.Errorf("can't find package %s", m[2])
+		}
+		return lookupMethod(pkg, m[1] == "*", m[3], m[4])
+	}
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		if pkg == nil { return nil, noPkg }
+		if fn := pkg.Func(name); fn != nil { return fn, nil }
+		return nil, fmt.Errorf("can't find function %s", name)
+	}
+	prefix, fnName := name[:i], name[i+1:]
+	if try := PackageByPathOrName(prefix); try != nil {
+		if fn := try.Func(fnName); fn != nil { return fn, nil }
+		return nil, fmt.Errorf("%s is not a function in package %s",
+			fnName, prefix)
+	}
+	// Not a package, so perhaps T.Method or pkg.T.Method.
+	typeName := prefix
+	if j := strings.LastIndex(prefix, "."); j > strings.LastIndex(prefix, "/") {
+		if pkg = PackageByPathOrName(prefix[:j]); pkg == nil {
+			return nil, fmt.Errorf("can't find package %s", prefix[:j])
+		}
+		typeName = prefix[j+1:]
+	}
+	if pkg == nil || pkg.Type(typeName) == nil {
+		return nil, fmt.Errorf("can't find package or type %s", prefix)
+	}
+	return lookupMethod(pkg, false, typeName, fnName)
+}
+
+// curPackage gives the package of the current frame, or nil if there
+// is none, e.g. in a synthetic function.
+func curPackage() *ssa2.Package {
+	if curFrame == nil || curFrame.Fn() == nil { return nil }
+	return curFrame.Fn().Pkg
+}
+
+// sameFile reports whether filename names the file in position
+// filename try, either exactly or as a trailing part of its path.
+func sameFile(try string, filename string) bool {
+	return try == filename || strings.HasSuffix(try, "/"+filename)
+}
+
+// locFn gives the function that location l is in.
+func locFn(l *ssa2.LocInst) *ssa2.Function {
+	if l.Fn != nil { return l.Fn }
+	return l.Trace.Parent()
+}
+
+// fnHasLine reports whether the source of fn spans line of filename.
+func fnHasLine(fn *ssa2.Function, filename string, line int) bool {
+	if fn == nil || !fn.Pos().IsValid() { return false }
+	fset := program.Fset
+	start, end := fset.Position(fn.Pos()), fset.Position(fn.EndP())
+	return sameFile(start.Filename, filename) &&
+		start.Line <= line && line <= end.Line
+}
+
+// enclosingFn gives the innermost function whose source spans line of
+// filename, or nil if line isn't in a function.
+func enclosingFn(filename string, line int) *ssa2.Function {
+	var inner *ssa2.Function
+	for _, pkg := range program.AllPackages() {
+		locs := pkg.Locs()
+		for i := range locs {
+			l := &locs[i]
+			if l.Trace == nil && l.Fn == nil { continue }
+			fn := locFn(l)
+			if !fnHasLine(fn, filename, line) { continue }
+			if inner == nil || fn.Pos() > inner.Pos() { inner = fn }
+		}
+	}
+	return inner
+}
+
+// StmtLoc finds the statement boundary for line and column of
+// filename. A column of -1 means any column. If there is nothing to
+// stop at on line itself, the nearest following line of the file that
+// has something is used instead, as long as it is in the same
+// function; a column is then ignored.
+func StmtLoc(filename string, line int, column int) (*BreakLoc, error) {
+	var best *ssa2.LocInst
+	var bestPos token.Position
+	fset := program.Fset
+	inFn := enclosingFn(filename, line)
+	for _, pkg := range program.AllPackages() {
+		locs := pkg.Locs()
+		for i := range locs {
+			l := &locs[i]
+			if l.Trace == nil && l.Fn == nil { continue }
+			try := fset.Position(l.Pos())
+			if try.Line < line || !sameFile(try.Filename, filename) {
+				continue
+			}
+			if inFn != nil && try.Line != line && locFn(l) != inFn {
+				continue
+			}
+			if column != -1 && try.Line == line && try.Column != column {
+				continue
+			}
+			if best != nil && try.Line >= bestPos.Line { continue }
+			best, bestPos = l, try
+		}
+	}
+	if best == nil || (column != -1 && bestPos.Line != line) {
+		suffix := ""
+		if column != -1 { suffix = fmt.Sprintf(", column %d", column) }
+		return nil, fmt.Errorf("can't find statement in file %s at line %d%s",
+			filename, line, suffix)
+	}
+	return &BreakLoc{Fn: best.Fn, Trace: best.Trace,
+		Pos: best.Pos(), EndP: best.EndP()}, nil
+}
+
+// ParseBreakLoc resolves the location arguments of a breakpoint-like
+// command. args is one of:
+//    *fn*                 a function or method; see LookupFunction
+//    *file*:*line*[:*col*] a line and optional column in a file
+//    *line* [*col*]        a line and optional column in the current file
+func ParseBreakLoc(args []string) (*BreakLoc, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("expecting a location")
+	}
+	if len(args) == 2 {
+		line, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("expecting a line number, got %s", args[0])
+		}
+		column, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("expecting a column number, got %s", args[1])
+		}
+		return curFileLoc(line, column)
+	}
+	arg := args[0]
+	if line, err := strconv.Atoi(arg); err == nil {
+		return curFileLoc(line, -1)
+	}
+	if !strings.HasPrefix(arg, "(") {
+		if parts := strings.Split(arg, ":"); len(parts) == 2 || len(parts) == 3 {
+			line, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("expecting a line number, got %s", parts[1])
+			}
+			column := -1
+			if len(parts) == 3 {
+				if column, err = strconv.Atoi(parts[2]); err != nil {
+					return nil, fmt.Errorf("expecting a column number, got %s",
+						parts[2])
+				}
+			}
+			return StmtLoc(parts[0], line, column)
+		}
+	}
+	fn, err := LookupFunction(arg)
+	if err != nil { return nil, err }
+	return &BreakLoc{Fn: fn, Pos: fn.Pos(), EndP: fn.EndP(), ByName: true}, nil
+}
+
+// curFileLoc finds line and column in the file of the current frame.
+func curFileLoc(line int, column int) (*BreakLoc, error) {
+	if curFrame == nil {
+		return nil, fmt.Errorf("no current file to look up line %d in", line)
+	}
+	position := curFrame.Position()
+	if !position.IsValid() {
+		return nil, fmt.Errorf("no current file to look up line %d in", line)
+	}
+	return StmtLoc(position.Filename, line, column)
+}
+
+// IsExternal reports whether fn is implemented natively by the
+// interpreter rather than by interpreting its SSA.
+func IsExternal(fn *ssa2.Function) bool {
+	return fn.Blocks == nil || interp.Externals()[fn.String()] != nil
+}
+
+// FunctionsMatching returns the functions of the program, sorted by
+// name, whose names match re. Synthetic wrappers and functions that
+// we can't stop in are left out.
+func FunctionsMatching(re *regexp.Regexp) []*ssa2.Function {
+	fns := make([]*ssa2.Function, 0)
+	for fn := range ssautil.AllFunctions(program) {
+		if fn.Synthetic != "" || IsExternal(fn) { continue }
+		if re.MatchString(fn.String()) {
+			fns = append(fns, fn)
+		}
+	}
+	sort.Sort(byFnName(fns))
+	return fns
+}
+
+type byFnName []*ssa2.Function
+
+func (s byFnName) Len() int           { return len(s) }
+func (s byFnName) Less(i, j int) bool { return s[i].String() < s[j].String() }
+func (s byFnName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,208 @@
+// Copyright 2015 Rocky Bernstein.
+// Listing source lines.
+
+package gub
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/rocky/ssa-interp/terminal"
+)
+
+// ListSize is the number of lines "list" shows by default.
+var ListSize = 10
+
+// The file and lines shown by the last "list". listCenter is set when
+// the next plain "list" should be around the current position
+// rather than continue from the last listing: after a stop or a frame
+// change.
+var listFile string
+var listFirst, listLast int
+var listCenter = true
+
+// sourceLines caches the lines of source files read for listing. The
+// highlighted form is kept separately.
+var sourceLines = make(map[string][]string)
+var highlightedLines = make(map[string][]string)
+
+// readSource gives the lines of file filename, highlighted if
+// highlighting is on.
+func readSource(filename string) ([]string, error) {
+	cache := sourceLines
+	if *Highlight { cache = highlightedLines }
+	if lines, ok := cache[filename]; ok { return lines, nil }
+	src, err := ioutil.ReadFile(filename)
+	if err != nil { return nil, err }
+	if *Highlight {
+		if highlighted, err := ansiterm.AsTerm(src, true); err == nil {
+			src = highlighted
+		}
+	}
+	lines := strings.Split(string(bytes.TrimRight(src, "\n")), "\n")
+	cache[filename] = lines
+	return lines, nil
+}
+
+// FindSourceFile finds the file of the program that filename names,
+// either exactly or as a trailing part of its path.
+func FindSourceFile(filename string) (string, error) {
+	found := ""
+	program.Fset.Iterate(func(f *token.File) bool {
+		if sameFile(f.Name(), filename) {
+			found = f.Name()
+			return false
+		}
+		return true
+	})
+	if found == "" {
+		return "", fmt.Errorf("can't find source file %s", filename)
+	}
+	return found, nil
+}
+
+// ListLoc resolves the location argument of "list": a line of the
+// current file, file:line or a function. It gives the file and line.
+func ListLoc(arg string) (string, int, error) {
+	if line, err := strconv.Atoi(arg); err == nil {
+		filename := listFile
+		if filename == "" || listCenter {
+			filename = curFrame.Position().Filename
+		}
+		if filename == "" {
+			return "", 0, fmt.Errorf("no current file to list line %d of", line)
+		}
+		return filename, line, nil
+	}
+	if i := strings.LastIndex(arg, ":"); i > 0 && !strings.HasPrefix(arg, "(") {
+		line, err := strconv.Atoi(arg[i+1:])
+		if err != nil {
+			return "", 0, fmt.Errorf("expecting a line number, got %s", arg[i+1:])
+		}
+		filename, err := FindSourceFile(arg[:i])
+		if err != nil { return "", 0, err }
+		return filename, line, nil
+	}
+	fn, err := LookupFunction(arg)
+	if err != nil { return "", 0, err }
+	if !fn.Pos().IsValid() {
+		return "", 0, fmt.Errorf("%s has no source position", arg)
+	}
+	position := program.Fset.Position(fn.Pos())
+	return position.Filename, position.Line, nil
+}
+
+// breakpointMarks gives, for each line of filename with a breakpoint,
+// whether any breakpoint on it is enabled.
+func breakpointMarks(filename string) map[int]bool {
+	marks := make(map[int]bool)
+	for _, bp := range Breakpoints {
+		if bp.Deleted || !bp.Pos.IsValid() { continue }
+		position := program.Fset.Position(bp.Pos)
+		if position.Filename != filename { continue }
+		marks[position.Line] = marks[position.Line] || bp.Enabled
+	}
+	return marks
+}
+
+// ListLines shows lines first through last of filename. Lines with
+// breakpoints are marked "B", or "b" if all of them are disabled, and
+// the line of the current position is marked "->".
+func ListLines(filename string, first int, last int) error {
+	lines, err := readSource(filename)
+	if err != nil { return err }
+	if first < 1 { first = 1 }
+	if last > len(lines) { last = len(lines) }
+	if first > len(lines) {
+		return fmt.Errorf("line number %d out of range; %s has %d lines",
+			first, filename, len(lines))
+	}
+	marks := breakpointMarks(filename)
+	here := 0
+	if curFrame != nil {
+		if position := curFrame.Position(); position.Filename == filename {
+			here = position.Line
+		}
+	}
+	for line := first; line <= last; line++ {
+		bpMark := " "
+		if enabled, ok := marks[line]; ok {
+			bpMark = "b"
+			if enabled { bpMark = "B" }
+		}
+		posMark := "  "
+		if line == here { posMark = "->" }
+		text := lines[line-1]
+		if *Highlight { text += ansiterm.ResetColor() }
+		Msg("%4d%s%s %s", line, bpMark, posMark, text)
+	}
+	listFile, listFirst, listLast = filename, first, last
+	listCenter = false
+	return nil
+}
+
+// ListAround shows ListSize lines centered on line of filename.
+func ListAround(filename string, line int) error {
+	first := line - ListSize/2
+	if first < 1 { first = 1 }
+	return ListLines(filename, first, first+ListSize-1)
+}
+
+// ListNext shows the lines after the last listing, or around the
+// current position if there hasn't been a listing since the program
+// stopped.
+func ListNext() error {
+	if listCenter || listFile == "" {
+		position := curFrame.Position()
+		if !position.IsValid() {
+			return fmt.Errorf("no current position to list around")
+		}
+		return ListAround(position.Filename, position.Line)
+	}
+	return ListLines(listFile, listLast+1, listLast+ListSize)
+}
+
+// ListPrevious shows the lines before the last listing.
+func ListPrevious() error {
+	if listCenter || listFile == "" {
+		position := curFrame.Position()
+		if !position.IsValid() {
+			return fmt.Errorf("no current position to list around")
+		}
+		last := position.Line - ListSize/2 - 1
+		if last < 1 {
+			return fmt.Errorf("already at the start of %s", position.Filename)
+		}
+		return ListLines(position.Filename, last-ListSize+1, last)
+	}
+	if listFirst <= 1 {
+		return fmt.Errorf("already at the start of %s", listFile)
+	}
+	return ListLines(listFile, listFirst-ListSize, listFirst-1)
+}
+
+// ListRange shows the lines of a "list N,M" argument. Either end can
+// be left out, and the first can be a location as for ListLoc.
+func ListRange(firstArg string, lastArg string) error {
+	if firstArg == "" {
+		filename, last, err := ListLoc(lastArg)
+		if err != nil { return err }
+		return ListLines(filename, last-ListSize+1, last)
+	}
+	filename, first, err := ListLoc(firstArg)
+	if err != nil { return err }
+	last := first + ListSize - 1
+	if lastArg != "" {
+		if last, err = strconv.Atoi(lastArg); err != nil {
+			return fmt.Errorf("expecting a line number, got %s", lastArg)
+		}
+		if last < first {
+			return fmt.Errorf("line %d is before line %d", last, first)
+		}
+	}
+	return ListLines(filename, first, last)
+}
@@ -38,6 +38,12 @@ func init() {
 		ssa2.SWITCH_COND     : "sw?",
 		ssa2.STMT_IN_LIST    : "---",
 		ssa2.PROGRAM_TERMINATION : "FIN",
+		ssa2.WATCHPOINT      : "w= ",
+		ssa2.RECOVER         : "oR ",
+		ssa2.GOROUTINE_START : "go>",
+		ssa2.GOROUTINE_EXIT  : "go<",
+		ssa2.POSTMORTEM      : "RIP",
+		ssa2.DEADLOCK        : "zZz",
 	}
 }
 
@@ -45,6 +51,7 @@ func printLocInfo(fr *interp.Frame, inst *ssa2.Instruction,
 	event ssa2.TraceEvent) {
 	defer func() {
 		if x := recover(); x != nil {
+			if interp.IsUnwind(x) { panic(x) }
 			Errmsg("Internal error in getting location info")
 			debug.PrintStack()
 		}
@@ -90,8 +97,23 @@ func printLocInfo(fr *interp.Frame, inst *ssa2.Instruction,
 				Msg("%s nil", p)
 			}
 		}
-	case ssa2.PANIC:
-		// fmt.Printf("panic arg: %s\n", fr.Get(instr.X))
+	case ssa2.PANIC, ssa2.RECOVER, ssa2.GOROUTINE_START, ssa2.GOROUTINE_EXIT:
+		if curBpnum != NoBp {
+			printCatch(fr, inst, Breakpoints[curBpnum])
+		}
+	case ssa2.POSTMORTEM:
+		Msg("Program panicked: %s", fr.PanicString())
+		Msg("Entering post-mortem debugging. The program can't be resumed.")
+	case ssa2.PROGRAM_TERMINATION:
+		PrintLeaks()
+	case ssa2.DEADLOCK:
+		Msg("All goroutines are asleep - deadlock!")
+		Msg("Entering post-mortem debugging. The program can't be resumed.")
+		Msg("\"goroutines\" shows what each goroutine is blocked in.")
+	case ssa2.WATCHPOINT:
+		if curWatch != nil {
+			printWatchChange(curWatch)
+		}
 	}
 
 	Msg(fr.PositionRange())
@@ -104,4 +126,8 @@ func printLocInfo(fr *interp.Frame, inst *ssa2.Instruction,
 			PrintSyntaxFirstLine(syntax, fn.Prog.Fset)
 		}
 	}
+	listCenter = true
+	if event != ssa2.PROGRAM_TERMINATION {
+		ShowDisplays()
+	}
 }
@@ -0,0 +1,122 @@
+// Copyright 2015 Rocky Bernstein.
+// Logpoints: breakpoints that print a message and keep going.
+
+package gub
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// logFile is where logpoint messages go. When it is nil, they go to
+// the debugger's message stream.
+var logFile *os.File
+var logFileName string
+
+// SetLogFile sends logpoint output to file name, appending to it. An
+// empty name sends output back to the message stream.
+func SetLogFile(name string) error {
+	if name == "" {
+		if logFile != nil { logFile.Close() }
+		logFile, logFileName = nil, ""
+		return nil
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil { return err }
+	if logFile != nil { logFile.Close() }
+	logFile, logFileName = f, name
+	return nil
+}
+
+// LogFileName is the name of the file logpoint output goes to, or ""
+// if it goes to the message stream.
+func LogFileName() string { return logFileName }
+
+// Logmsg writes a logpoint message.
+func Logmsg(format string, a ...interface{}) (n int, err error) {
+	if logFile != nil {
+		return fmt.Fprintf(logFile, format + "\n", a...)
+	}
+	return Msg(format, a...)
+}
+
+// CheckLogFormat makes sure the braces in a logpoint format string
+// match up. "{{" and "}}" stand for literal braces.
+func CheckLogFormat(format string) error {
+	_, err := expandLogFormat(format, func(string) string { return "" })
+	return err
+}
+
+// expandLogFormat replaces each {expr} in format with eval(expr).
+func expandLogFormat(format string, eval func(string) string) (string, error) {
+	var buf bytes.Buffer
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '{' && strings.HasPrefix(format[i:], "{{"):
+			buf.WriteByte('{')
+			i++
+		case c == '}' && strings.HasPrefix(format[i:], "}}"):
+			buf.WriteByte('}')
+			i++
+		case c == '{':
+			j := strings.IndexByte(format[i:], '}')
+			if j < 0 {
+				return "", fmt.Errorf("unmatched { in %s", format)
+			}
+			expr := strings.TrimSpace(format[i+1 : i+j])
+			if expr == "" {
+				return "", fmt.Errorf("empty {} in %s", format)
+			}
+			buf.WriteString(eval(expr))
+			i += j
+		case c == '}':
+			return "", fmt.Errorf("unmatched } in %s", format)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String(), nil
+}
+
+// logValue evaluates expr in frame fr and formats the result for a
+// logpoint message.
+func logValue(fr *interp.Frame, expr string) string {
+	results, err := EvalExprInFrame(fr, expr)
+	if err != nil { return fmt.Sprintf("<error: %s>", err.Error()) }
+	return FormatResults(results)
+}
+
+// FormatResults formats the results of EvalExprInFrame for showing,
+// separated by commas.
+func FormatResults(results []reflect.Value) string {
+	strs := make([]string, len(results))
+	for i, rv := range results {
+		strs[i] = formatReflectValue(rv)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// formatReflectValue gives the %v format of rv.
+func formatReflectValue(rv reflect.Value) string {
+	if !rv.IsValid() { return "nil" }
+	if !rv.CanInterface() { return rv.String() }
+	return fmt.Sprintf("%v", rv.Interface())
+}
+
+// logpointPrint prints the message for logpoint bp hit in frame fr.
+func logpointPrint(bp *Breakpoint, fr *interp.Frame) {
+	msg, err := expandLogFormat(bp.Log, func(expr string) string {
+		return logValue(fr, expr)
+	})
+	if err != nil {
+		Errmsg("Logpoint %d: %s", bp.Id, err.Error())
+		return
+	}
+	Logmsg("%s", msg)
+}
@@ -0,0 +1,315 @@
+// Copyright 2015 Rocky Bernstein.
+// Resolving expressions for variables, fields and elements to the
+// locations in the program that hold them.
+
+package gub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"reflect"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// An lvalue is a location that holds a value of the program: a
+// variable, a field of a struct, an element of a slice or array, or an
+// entry of a map.
+type lvalue struct {
+	read     watchRead
+	write    func(interp.Value) error
+	typ      types.Type
+	local    bool // depends on a local variable of the frame
+	register bool // an SSA value rather than something in memory
+	addr     func() *interp.Value // address of a variable; nil if unknown
+}
+
+// errNoLocation is the error for writing to a location that doesn't
+// exist, e.g. through a nil pointer.
+var errNoLocation = errors.New("location doesn't exist")
+
+// lvalueIdent resolves a variable name.
+func lvalueIdent(fr *interp.Frame, name string) (*lvalue, error) {
+	nameVal, interpVal, _ := EnvLookup(fr, name, curScope)
+	switch v := nameVal.(type) {
+	case *ssa2.Alloc:
+		addr := func() *interp.Value {
+			addr, _ := fr.Env()[v].(*interp.Value)
+			return addr
+		}
+		return &lvalue{
+			read: func() (interp.Value, bool) {
+				a := addr()
+				if a == nil { return nil, false }
+				return *a, true
+			},
+			write: func(val interp.Value) error {
+				a := addr()
+				if a == nil { return errNoLocation }
+				interp.Store(fr, a, val)
+				return nil
+			},
+			typ: deref(v.Type()), local: true, addr: addr,
+		}, nil
+	case *ssa2.Global:
+		g, ok := fr.I().Global(name, v.Pkg)
+		if !ok { break }
+		return globalLvalue(fr, g, deref(v.Type())), nil
+	case nil:
+	default:
+		if interpVal != nil {
+			// A parameter or other SSA value kept in the frame's
+			// environment rather than in memory.
+			return &lvalue{
+				read: func() (interp.Value, bool) {
+					val, ok := fr.Env()[nameVal]
+					return val, ok
+				},
+				write: func(val interp.Value) error {
+					fr.Env()[nameVal] = val
+					return nil
+				},
+				typ: nameVal.Type(), local: true, register: true,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("can't find variable %s", name)
+}
+
+// globalLvalue is the lvalue for the package variable stored at g.
+func globalLvalue(fr *interp.Frame, g *interp.Value, typ types.Type) *lvalue {
+	return &lvalue{
+		read: func() (interp.Value, bool) { return *g, true },
+		write: func(val interp.Value) error {
+			interp.Store(fr, g, val)
+			return nil
+		},
+		typ: typ,
+		addr: func() *interp.Value { return g },
+	}
+}
+
+// lvaluePkgVar resolves pkg.name, a package variable.
+func lvaluePkgVar(fr *interp.Frame, pkgName string, name string) (*lvalue, error) {
+	pkg := PkgLookup(pkgName)
+	if pkg == nil {
+		return nil, fmt.Errorf("can't find package %s", pkgName)
+	}
+	v := pkg.Var(name)
+	if v == nil {
+		return nil, fmt.Errorf("%s is not a variable of %s", name, pkgName)
+	}
+	g, ok := fr.I().Global(name, pkg)
+	if !ok {
+		return nil, fmt.Errorf("no storage for %s.%s", pkgName, name)
+	}
+	return globalLvalue(fr, g, deref(v.Type())), nil
+}
+
+// lvalueIndex evaluates the index or key expression of an element
+// once, when the location is resolved.
+func lvalueIndex(fr *interp.Frame, expr ast.Expr, typ types.Type) (interp.Value, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return nil, err
+	}
+	str := buf.String()
+	results, err := EvalExprInFrame(fr, str)
+	if err != nil { return nil, err }
+	if len(results) != 1 {
+		return nil, fmt.Errorf("%s should have a single value", str)
+	}
+	return Reflect2InterpVal(results[0], typ)
+}
+
+// derefRead turns a read of a pointer into a read of what it points
+// to.
+func derefRead(read watchRead) func() (*interp.Value, bool) {
+	return func() (*interp.Value, bool) {
+		v, ok := read()
+		if !ok { return nil, false }
+		addr, ok := v.(*interp.Value)
+		return addr, ok && addr != nil
+	}
+}
+
+// resolveLvalue resolves expr, which names a location, in frame fr.
+func resolveLvalue(fr *interp.Frame, expr ast.Expr) (*lvalue, error) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return resolveLvalue(fr, e.X)
+
+	case *ast.Ident:
+		return lvalueIdent(fr, e.Name)
+
+	case *ast.StarExpr:
+		lv, err := resolveLvalue(fr, e.X)
+		if err != nil { return nil, err }
+		ptr, ok := lv.typ.Underlying().(*types.Pointer)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a pointer", lv.typ)
+		}
+		target := derefRead(lv.read)
+		return &lvalue{
+			read: func() (interp.Value, bool) {
+				addr, ok := target()
+				if !ok { return nil, false }
+				return *addr, true
+			},
+			write: func(val interp.Value) error {
+				addr, ok := target()
+				if !ok { return errNoLocation }
+				interp.Store(fr, addr, val)
+				return nil
+			},
+			typ: ptr.Elem(), local: lv.local,
+			addr: func() *interp.Value {
+				addr, _ := target()
+				return addr
+			},
+		}, nil
+
+	case *ast.SelectorExpr:
+		if id, ok := e.X.(*ast.Ident); ok {
+			if nameVal, _, _ := EnvLookup(fr, id.Name, curScope); nameVal == nil {
+				if PkgLookup(id.Name) != nil {
+					return lvaluePkgVar(fr, id.Name, e.Sel.Name)
+				}
+			}
+		}
+		lv, err := resolveLvalue(fr, e.X)
+		if err != nil { return nil, err }
+		typ := lv.typ
+		read := lv.read
+		register := lv.register
+		if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = ptr.Elem()
+			register = false
+			target := derefRead(lv.read)
+			read = func() (interp.Value, bool) {
+				addr, ok := target()
+				if !ok { return nil, false }
+				return *addr, true
+			}
+		}
+		st, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct", typ)
+		}
+		field := -1
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i).Name() == e.Sel.Name {
+				field = i
+				break
+			}
+		}
+		if field < 0 {
+			return nil, fmt.Errorf("%s has no field %s", typ, e.Sel.Name)
+		}
+		record := func() (interp.Structure, bool) {
+			v, ok := read()
+			if !ok { return interp.Structure{}, false }
+			record, ok := v.(interp.Structure)
+			return record, ok
+		}
+		return &lvalue{
+			read: func() (interp.Value, bool) {
+				record, ok := record()
+				if !ok { return nil, false }
+				fv, err := record.Field(field)
+				return fv, err == nil
+			},
+			write: func(val interp.Value) error {
+				record, ok := record()
+				if !ok { return errNoLocation }
+				addr, err := record.FieldAddr(field)
+				if err != nil { return err }
+				interp.Store(fr, addr, val)
+				return nil
+			},
+			typ: st.Field(field).Type(), local: lv.local, register: register,
+		}, nil
+
+	case *ast.IndexExpr:
+		lv, err := resolveLvalue(fr, e.X)
+		if err != nil { return nil, err }
+		typ := lv.typ
+		read := lv.read
+		register := lv.register
+		if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = ptr.Elem()
+			register = false
+			target := derefRead(lv.read)
+			read = func() (interp.Value, bool) {
+				addr, ok := target()
+				if !ok { return nil, false }
+				return *addr, true
+			}
+		}
+		switch t := typ.Underlying().(type) {
+		case *types.Map:
+			key, err := lvalueIndex(fr, e.Index, t.Key())
+			if err != nil { return nil, err }
+			return &lvalue{
+				read: func() (interp.Value, bool) {
+					m, ok := read()
+					if !ok { return nil, false }
+					return interp.MapLookup(m, key)
+				},
+				write: func(val interp.Value) error {
+					m, ok := read()
+					if !ok || !interp.MapUpdate(fr, m, key, val) {
+						return errors.New("can't assign to an entry of a nil map")
+					}
+					return nil
+				},
+				typ: t.Elem(), local: lv.local,
+			}, nil
+		case *types.Slice, *types.Array:
+			var elem types.Type
+			if s, ok := t.(*types.Slice); ok {
+				// The elements are in memory even if the slice isn't.
+				elem, register = s.Elem(), false
+			} else {
+				elem = t.(*types.Array).Elem()
+			}
+			idx, err := lvalueIndex(fr, e.Index, types.Typ[types.Int])
+			if err != nil { return nil, err }
+			n := idx.(int)
+			elems := func() (reflect.Value, bool) {
+				v, ok := read()
+				if !ok { return reflect.Value{}, false }
+				elems := reflect.ValueOf(v)
+				if elems.Kind() != reflect.Slice || n < 0 || n >= elems.Len() {
+					return reflect.Value{}, false
+				}
+				return elems, true
+			}
+			return &lvalue{
+				read: func() (interp.Value, bool) {
+					elems, ok := elems()
+					if !ok { return nil, false }
+					return elems.Index(n).Interface(), true
+				},
+				write: func(val interp.Value) error {
+					elems, ok := elems()
+					if !ok {
+						return fmt.Errorf("index %d out of range", n)
+					}
+					addr := elems.Index(n).Addr().Interface().(*interp.Value)
+					interp.Store(fr, addr, val)
+					return nil
+				},
+				typ: elem, local: lv.local, register: register,
+			}, nil
+		}
+		return nil, fmt.Errorf("can't index %s", typ)
+	}
+	return nil, fmt.Errorf("expecting a variable, field or element")
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 Rocky Bernstein.
+// Making a frame of the program return early.
+
+package gub
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// returnValues computes the values of the comma-separated expressions
+// in exprs, for the results of the function of frame fr. With no
+// expressions, the results are the zero values of their types, or nil
+// if the results are named, since they then keep their values.
+func returnValues(fr *interp.Frame, exprs string) ([]interp.Value, error) {
+	results := fr.Fn().Signature.Results()
+	if exprs == "" {
+		if results.Len() > 0 && results.At(0).Name() != "" {
+			// A bare return keeps the values of the named results.
+			return nil, nil
+		}
+		vals := make([]interp.Value, results.Len())
+		for i := range vals {
+			vals[i] = interp.Zero(results.At(i).Type())
+		}
+		return vals, nil
+	}
+	// Parse the list as the arguments of a call, to split it at the
+	// right commas.
+	expr, err := parser.ParseExpr("f(" + exprs + ")")
+	if err != nil { return nil, err }
+	args := expr.(*ast.CallExpr).Args
+	if len(args) != results.Len() {
+		return nil, fmt.Errorf("%s returns %d value(s); %d given",
+			fr.Fn().Name(), results.Len(), len(args))
+	}
+	vals := make([]interp.Value, len(args))
+	for i, arg := range args {
+		v, err := assignValue(fr, exprString(arg), results.At(i).Type())
+		if err != nil {
+			if len(args) == 1 { return nil, err }
+			return nil, fmt.Errorf("result %d: %s", i+1, err.Error())
+		}
+		vals[i] = v
+	}
+	return vals, nil
+}
+
+// ReturnFromFrame makes frame fr return right away with the values of
+// exprs as its results. Deferred calls of fr and of the frames it has
+// called run first. ReturnFromFrame doesn't return unless there is an
+// error.
+func ReturnFromFrame(fr *interp.Frame, exprs string) error {
+	if Replaying() {
+		return errors.New("can't return while going over recorded history")
+	}
+	if fr == nil || stopFrame == nil || fr.GoNum() != stopFrame.GoNum() {
+		return errors.New("the frame isn't in the goroutine that is stopped")
+	}
+	if fr.Status() != interp.StRunning {
+		return errors.New("the frame isn't running")
+	}
+	vals, err := returnValues(fr, exprs)
+	if err != nil { return err }
+	return interp.ForceReturn(fr, vals)
+}
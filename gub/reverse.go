@@ -0,0 +1,157 @@
+// Copyright 2015 Rocky Bernstein.
+// Going backwards and forwards over recorded execution history.
+
+package gub
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// liveStop is where the program really stopped, saved when we first
+// go back in the execution history so that we can show it again when
+// we come back.
+type liveStop struct {
+	fr    *interp.Frame
+	instr *ssa2.Instruction
+	event ssa2.TraceEvent
+}
+
+var live liveStop
+
+// Replaying reports whether we have gone back in the execution
+// history.
+func Replaying() bool {
+	return interp.HistoryCursor() < interp.HistoryLen()
+}
+
+// historyPos is the history entry we are at. At the present this is
+// the last entry if we stopped exactly there, and HistoryLen()
+// otherwise.
+func historyPos() int {
+	pos := interp.HistoryCursor()
+	n := interp.HistoryLen()
+	if pos == n && n > 0 && interp.HistoryEntryAt(n-1).IsAt(stopFrame) {
+		pos = n-1
+	}
+	return pos
+}
+
+// historyFrame is the frame for history position pos.
+func historyFrame(pos int) *interp.Frame {
+	if pos == interp.HistoryLen() { return live.fr }
+	return interp.HistoryEntryAt(pos).Frame()
+}
+
+// saveLive saves where the program really stopped, unless we have
+// already gone back in the history.
+func saveLive() {
+	if !Replaying() {
+		live = liveStop{fr: stopFrame, instr: Instr, event: TraceEvent}
+	}
+}
+
+// historyGoto moves to history position pos and shows where that is.
+func historyGoto(pos int) {
+	interp.HistoryGoto(pos)
+	if pos == interp.HistoryLen() {
+		Msg("Back at the present.")
+		TraceEvent = live.event
+		Instr = live.instr
+		frameInit(live.fr)
+		printLocInfo(live.fr, live.instr, live.event)
+		return
+	}
+	e := interp.HistoryEntryAt(pos)
+	var instr ssa2.Instruction = e.Trace
+	TraceEvent = e.Trace.Event
+	Instr = &instr
+	frameInit(e.Frame())
+	printLocInfo(topFrame, Instr, TraceEvent)
+}
+
+// historyBreakpointAt reports whether a breakpoint would have stopped
+// the program at history entry e. The program state must be that of
+// e.
+func historyBreakpointAt(e *interp.HistoryEntry) bool {
+	fr := e.Frame()
+	for _, bpnum := range BreakpointFindByPos(e.Trace.Start) {
+		bp := Breakpoints[bpnum]
+		if bp.Enabled && bp.Log == "" && BreakpointCondTrue(bp, fr) {
+			return true
+		}
+	}
+	if !e.First || !fr.Fn().Breakpoint { return false }
+	for _, bpnum := range BreakpointFindByPos(fr.Fn().Pos()) {
+		bp := Breakpoints[bpnum]
+		if bp.Enabled && bp.Log == "" && BreakpointCondTrue(bp, fr) {
+			return true
+		}
+	}
+	return false
+}
+
+// HistoryStepKind says where a movement in the history stops.
+type HistoryStepKind int
+
+const (
+	HISTORY_STEP HistoryStepKind = iota  // the next statement of the goroutine
+	HISTORY_NEXT                         // the next statement not in a callee
+	HISTORY_CONTINUE                     // the next breakpoint
+)
+
+// historyStops reports whether moving by kind from frame fr stops at
+// history entry e.
+func historyStops(kind HistoryStepKind, fr *interp.Frame, e *interp.HistoryEntry) bool {
+	efr := e.Frame()
+	switch kind {
+	case HISTORY_STEP:
+		return efr.GoNum() == fr.GoNum()
+	case HISTORY_NEXT:
+		return efr.GoNum() == fr.GoNum() && !e.InStack(fr)
+	}
+	return historyBreakpointAt(e)
+}
+
+// HistoryBackward goes back in the execution history by kind. It
+// returns false if there is no more history.
+func HistoryBackward(kind HistoryStepKind) bool {
+	saveLive()
+	pos := historyPos()
+	fr := historyFrame(pos)
+	for k := pos-1; k >= 0; k-- {
+		e := interp.HistoryEntryAt(k)
+		if kind == HISTORY_CONTINUE { interp.HistoryGoto(k) }
+		if historyStops(kind, fr, e) {
+			historyGoto(k)
+			return true
+		}
+	}
+	if pos > 0 { historyGoto(0) }
+	Msg("No more reverse-execution history.")
+	return false
+}
+
+// HistoryForward goes forward over the execution history by kind,
+// returning to the present at the end of it.
+func HistoryForward(kind HistoryStepKind) {
+	saveLive()
+	pos := historyPos()
+	fr := historyFrame(pos)
+	n := interp.HistoryLen()
+	for k := pos+1; k < n; k++ {
+		e := interp.HistoryEntryAt(k)
+		if kind == HISTORY_CONTINUE { interp.HistoryGoto(k) }
+		if historyStops(kind, fr, e) {
+			historyGoto(k)
+			return
+		}
+	}
+	historyGoto(n)
+}
+
+// HistoryPresent returns from the execution history to where the
+// program really stopped.
+func HistoryPresent() {
+	if Replaying() { historyGoto(interp.HistoryLen()) }
+}
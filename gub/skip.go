@@ -0,0 +1,110 @@
+// Copyright 2015 Rocky Bernstein.
+// Skips: functions that "step" steps over rather than into.
+
+package gub
+
+import (
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"strings"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A Skip names functions that stepping in steps over.
+type Skip struct {
+	Id      int    // number shown and given to "skip delete"
+	Kind    string // "function", "package" or "file"
+	Pattern string // function name, package path or file glob
+}
+
+// Skips holds the skips, in the order they were added.
+var Skips []*Skip
+
+var lastSkipId = 0
+
+// SkipGoroot is set if the functions of packages in GOROOT are
+// skipped, which is the default.
+var SkipGoroot = true
+
+// gorootPkgs caches whether each package is in GOROOT.
+var gorootPkgs = make(map[*ssa2.Package]bool)
+
+// SkipAdd adds a skip of functions of kind kind matching pattern.
+func SkipAdd(kind string, pattern string) (*Skip, error) {
+	switch kind {
+	case "file":
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad file pattern %s: %s", pattern, err.Error())
+		}
+	case "function", "package":
+	default:
+		return nil, fmt.Errorf("unknown kind of skip %s", kind)
+	}
+	lastSkipId++
+	skip := &Skip{Id: lastSkipId, Kind: kind, Pattern: pattern}
+	Skips = append(Skips, skip)
+	return skip, nil
+}
+
+// SkipDelete deletes skip id. It returns false if there is no such
+// skip.
+func SkipDelete(id int) bool {
+	for k, skip := range Skips {
+		if skip.Id == id {
+			Skips = append(Skips[:k], Skips[k+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether function fn is one that skip names.
+func (skip *Skip) matches(fn *ssa2.Function) bool {
+	switch skip.Kind {
+	case "function":
+		return fn.Name() == skip.Pattern || fn.String() == skip.Pattern
+	case "package":
+		if fn.Pkg == nil { return false }
+		path := fn.Pkg.Object.Path()
+		if strings.HasSuffix(skip.Pattern, "/...") {
+			// The package and those below it, as for the go tool.
+			prefix := strings.TrimSuffix(skip.Pattern, "/...")
+			return path == prefix || strings.HasPrefix(path, prefix + "/")
+		}
+		return path == skip.Pattern
+	case "file":
+		if !fn.Pos().IsValid() { return false }
+		file := fn.Prog.Fset.Position(fn.Pos()).Filename
+		if ok, _ := filepath.Match(skip.Pattern, file); ok { return true }
+		ok, _ := filepath.Match(skip.Pattern, filepath.Base(file))
+		return ok
+	}
+	return false
+}
+
+// inGoroot reports whether fn is in a package of the Go
+// distribution.
+func inGoroot(fn *ssa2.Function) bool {
+	pkg := fn.Pkg
+	if pkg == nil { return false }
+	in, ok := gorootPkgs[pkg]
+	if !ok {
+		p, err := build.Default.Import(pkg.Object.Path(), "", build.FindOnly)
+		in = err == nil && p.Goroot
+		gorootPkgs[pkg] = in
+	}
+	return in
+}
+
+// skipFunction reports whether stepping in should step over calls of
+// fn. It is the interpreter's StepInSkip hook.
+func skipFunction(fn *ssa2.Function) bool {
+	// "step into" has picked the call it wants to stop in.
+	if intoCall != nil { return false }
+	for _, skip := range Skips {
+		if skip.matches(fn) { return true }
+	}
+	return SkipGoroot && inGoroot(fn)
+}
@@ -0,0 +1,58 @@
+// Copyright 2015 Rocky Bernstein.
+// Reading debugger commands from files.
+
+package gub
+
+import (
+	"bufio"
+	"os"
+
+	"code.google.com/p/go-gnureadline"
+)
+
+// An inputSource is a file of debugger commands being read.
+type inputSource struct {
+	name   string
+	file   *os.File
+	reader *bufio.Reader
+}
+
+// inputStack holds the command files being read, innermost last.
+// When the innermost one is used up we go back to the one before it,
+// and finally to the terminal.
+var inputStack []inputSource
+
+// stdinIsTerminal reports whether standard input is interactive.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// SourceFile arranges for debugger commands to be read from file name
+// before any further input.
+func SourceFile(name string) error {
+	file, err := os.Open(name)
+	if err != nil { return err }
+	inputStack = append(inputStack, inputSource{
+		name: name,
+		file: file,
+		reader: bufio.NewReader(file),
+	})
+	return nil
+}
+
+// ReadLine reads the next line of debugger input. This comes from a
+// sourced command file if there is one, and otherwise from the command
+// file given by -cmdfile or interactively using prompt.
+func ReadLine(prompt string) (string, error) {
+	for n := len(inputStack); n > 0; n = len(inputStack) {
+		line, err := inputStack[n-1].reader.ReadString('\n')
+		if err == nil || len(line) > 0 { return line, nil }
+		inputStack[n-1].file.Close()
+		inputStack = inputStack[:n-1]
+	}
+	if inputReader != nil {
+		return inputReader.ReadString('\n')
+	}
+	return gnureadline.Readline(prompt, true)
+}
@@ -0,0 +1,238 @@
+// Copyright 2015 Rocky Bernstein.
+// Stepping that takes more than one stop: repeat counts, until,
+// advance and stepping into a particular call. Stepping is in the
+// goroutine it was started from.
+
+package gub
+
+import (
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// StepKind is the kind of step that a repeat count repeats.
+type StepKind int
+
+const (
+	STEP_IN StepKind = iota
+	STEP_OVER
+	STEP_INSTRUCTION
+)
+
+// stepsLeft is the number of steps of kind stepKind still to take
+// before stopping.
+var stepsLeft int
+var stepKind StepKind
+
+// untilFrame is the frame that "until" runs in, stopping at a line
+// past untilLine. It is nil when no "until" is in progress.
+var untilFrame *interp.Frame
+var untilLine int
+
+// advanceInfo is the location an "advance" runs to. A breakpoint
+// flag is set at the location for the duration; wasSet records
+// whether it was set before.
+type advanceInfo struct {
+	loc    *BreakLoc
+	fr     *interp.Frame // frame advance was given in
+	wasSet bool
+}
+
+var advance *advanceInfo
+
+// intoCall is the call that "step into" stops at the entry of. It is
+// made from frame intoFrame.
+var intoFrame *interp.Frame
+var intoCall *ssa2.Call
+
+// stepGoNum is the goroutine that stepping was started in, or -1 if
+// no stepping is in progress. Step stops in other goroutines are
+// ignored.
+var stepGoNum = -1
+
+// setStep sets frame fr to take a step of kind kind.
+func setStep(fr *interp.Frame, kind StepKind) {
+	switch kind {
+	case STEP_IN:
+		interp.SetStepIn(fr)
+	case STEP_OVER:
+		interp.SetStepOver(fr)
+	case STEP_INSTRUCTION:
+		interp.SetStepInstruction(fr)
+	}
+}
+
+// Step starts count steps of kind kind from frame fr. The debugger
+// stops only after the last of them, or earlier at a breakpoint,
+// watchpoint or catchpoint.
+func Step(fr *interp.Frame, kind StepKind, count int) {
+	stepsLeft, stepKind = count-1, kind
+	stepGoNum = fr.GoNum()
+	setStep(fr, kind)
+}
+
+// Until runs frame fr until it reaches a line greater than the
+// current one, or returns. This gets us out of loops.
+func Until(fr *interp.Frame) {
+	untilFrame, untilLine = fr, fr.Position().Line
+	stepGoNum = fr.GoNum()
+	interp.SetStepOver(fr)
+}
+
+// Advance runs until location loc is reached or frame fr returns,
+// without leaving a breakpoint at loc.
+func Advance(fr *interp.Frame, loc *BreakLoc) {
+	advance = &advanceInfo{loc: loc, fr: fr}
+	stepGoNum = fr.GoNum()
+	if loc.Trace != nil {
+		advance.wasSet = loc.Trace.Breakpoint
+		loc.Trace.Breakpoint = true
+	} else {
+		advance.wasSet = interp.IsFnBreakpoint(loc.Fn)
+		interp.SetFnBreakpoint(loc.Fn)
+	}
+	for f := fr; f != nil; f = f.Caller(0) {
+		interp.SetStepOff(f)
+	}
+	interp.SetStepOut(fr)
+}
+
+// Finish runs until frame fr returns.
+func Finish(fr *interp.Frame) {
+	stepGoNum = fr.GoNum()
+	interp.SetStepOut(fr)
+}
+
+// StatementCalls gives the calls, in the order they are made, of the
+// statement that frame fr is stopped at. Calls of built-in functions
+// are left out since they can't be stepped into.
+func StatementCalls(fr *interp.Frame) []*ssa2.Call {
+	var calls []*ssa2.Call
+	if fr.Block() == nil { return nil }
+	instrs := fr.Block().Instrs
+	start := fr.PC()
+	if start < len(instrs) {
+		if _, ok := instrs[start].(*ssa2.Trace); ok { start++ }
+	}
+	for _, instr := range instrs[start:] {
+		if _, ok := instr.(*ssa2.Trace); ok { break }
+		call, ok := instr.(*ssa2.Call)
+		if !ok { continue }
+		if _, ok := call.Call.Value.(*ssa2.Builtin); ok { continue }
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// CallName gives the name of the function that call calls, for
+// choosing it in "step into".
+func CallName(call *ssa2.Call) string {
+	c := &call.Call
+	if fn := c.StaticCallee(); fn != nil { return fn.Name() }
+	if c.IsInvoke() { return c.Method.Name() }
+	return c.Value.Name()
+}
+
+// StepInto runs frame fr until call, one of its StatementCalls, is
+// made and stops at the entry of the function called. The calls
+// before it run without stopping.
+func StepInto(fr *interp.Frame, call *ssa2.Call) {
+	intoFrame, intoCall = fr, call
+	stepGoNum = fr.GoNum()
+	interp.SetStepIn(fr)
+}
+
+// intoReached decides whether a stop for event in frame fr ends a
+// "step into". If it doesn't, the program carries on.
+func intoReached(fr *interp.Frame, event ssa2.TraceEvent) bool {
+	if fr == intoFrame {
+		// We got past the statement without making the call.
+		Msg("%s was not called.", CallName(intoCall))
+		return true
+	}
+	if event != ssa2.CALL_ENTER || fr.Caller(0) != intoFrame {
+		return false
+	}
+	if intoFrame.Block().Instrs[intoFrame.PC()] == intoCall { return true }
+	// Another call of the statement: run it without stopping.
+	interp.SetStepOff(fr)
+	interp.SetStepIn(intoFrame)
+	return false
+}
+
+// advanceReached reports whether frame fr has stopped at the
+// location of an "advance".
+func advanceReached(fr *interp.Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) bool {
+	if advance == nil || !atBreakpoint(instr, event) { return false }
+	if advance.loc.Trace != nil {
+		return fr.StartP() == advance.loc.Pos
+	}
+	return event == ssa2.BREAKPOINT && fr.Fn() == advance.loc.Fn && fr.PC() == 0
+}
+
+// stopStepping cancels any stepping that is in progress.
+func stopStepping() {
+	stepsLeft = 0
+	stepGoNum = -1
+	untilFrame = nil
+	intoFrame, intoCall = nil, nil
+	if advance != nil {
+		if loc := advance.loc; loc.Trace != nil {
+			loc.Trace.Breakpoint = advance.wasSet
+		} else if !advance.wasSet {
+			interp.ClearFnBreakpoint(loc.Fn)
+		}
+		advance = nil
+	}
+}
+
+// otherGoroutineStep reports whether a stop for event in frame fr is
+// a step stop in a goroutine other than the one being stepped, which
+// should not stop the program. Breakpoints, watchpoints, catchpoints
+// and the end of the program stop it in any goroutine.
+func otherGoroutineStep(fr *interp.Frame, event ssa2.TraceEvent) bool {
+	if stepGoNum < 0 || fr.GoNum() == stepGoNum { return false }
+	switch event {
+	case ssa2.PROGRAM_TERMINATION, ssa2.POSTMORTEM, ssa2.DEADLOCK,
+		ssa2.WATCHPOINT:
+		return false
+	}
+	return !isCatchEvent(event) && curBpnum == NoBp
+}
+
+// keepStepping decides whether a stop for event in frame fr is part
+// of a repeated step, an "until" or a "step into" that has further
+// to go. If so, the next step is set up. Otherwise any stepping in
+// progress is over.
+func keepStepping(fr *interp.Frame, event ssa2.TraceEvent) bool {
+	switch event {
+	case ssa2.PROGRAM_TERMINATION, ssa2.POSTMORTEM, ssa2.DEADLOCK,
+		ssa2.WATCHPOINT:
+		stopStepping()
+		return false
+	}
+	if isCatchEvent(event) || curBpnum != NoBp {
+		stopStepping()
+		return false
+	}
+	switch {
+	case intoCall != nil:
+		if !intoReached(fr, event) { return true }
+	case untilFrame != nil:
+		if fr == untilFrame && event != ssa2.CALL_RETURN &&
+			fr.Position().Line <= untilLine {
+			interp.SetStepOver(fr)
+			return true
+		}
+	case stepsLeft > 0:
+		stepsLeft--
+		if event == ssa2.CALL_RETURN && fr.Caller(0) != nil {
+			// Carry on stepping in the caller.
+			fr = fr.Caller(0)
+		}
+		setStep(fr, stepKind)
+		return true
+	}
+	stopStepping()
+	return false
+}
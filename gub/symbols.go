@@ -0,0 +1,145 @@
+// Copyright 2015 Rocky Bernstein.
+// Searching the whole program for functions, variables, types and
+// methods.
+
+package gub
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/ssautil"
+)
+
+// A Symbol is something of the program found by a search, with a
+// description of it and where it is.
+type Symbol struct {
+	Name  string
+	Desc  string
+	Where string
+}
+
+type bySymbolName []Symbol
+
+func (s bySymbolName) Len() int           { return len(s) }
+func (s bySymbolName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+func (s bySymbolName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// posRange gives where start through end is, or "-" if there is no
+// position, e.g. for a synthetic wrapper.
+func posRange(start token.Pos, end token.Pos) string {
+	return ssa2.FmtRangeWithFset(program.Fset, start, end)
+}
+
+// fnSymbol describes function fn, noting if it is synthetic.
+func fnSymbol(fn *ssa2.Function) Symbol {
+	desc := fn.Signature.String()
+	if fn.Synthetic != "" { desc += " [" + fn.Synthetic + "]" }
+	return Symbol{Name: fn.String(), Desc: desc,
+		Where: ssa2.FmtRange(fn, fn.Pos(), fn.EndP())}
+}
+
+// FunctionSymbols finds the functions and methods of the program,
+// including synthetic wrappers, whose names match re.
+func FunctionSymbols(re *regexp.Regexp) []Symbol {
+	var syms []Symbol
+	for fn := range ssautil.AllFunctions(program) {
+		if re.MatchString(fn.String()) {
+			syms = append(syms, fnSymbol(fn))
+		}
+	}
+	sort.Sort(bySymbolName(syms))
+	return syms
+}
+
+// memberSymbols finds the package members with token tok whose
+// qualified names match re.
+func memberSymbols(re *regexp.Regexp, tok token.Token) []Symbol {
+	var syms []Symbol
+	for _, pkg := range program.AllPackages() {
+		for _, mem := range pkg.Members {
+			if mem.Token() != tok { continue }
+			name := pkg.Object.Path() + "." + mem.Name()
+			if !re.MatchString(name) { continue }
+			var sym Symbol
+			switch mem := mem.(type) {
+			case *ssa2.Global:
+				sym = Symbol{Name: name, Desc: deref(mem.Type()).String(),
+					Where: posRange(mem.Pos(), mem.EndP())}
+			case *ssa2.Type:
+				sym = Symbol{Name: name, Desc: mem.Type().Underlying().String(),
+					Where: posRange(mem.Pos(), mem.Pos())}
+			default:
+				continue
+			}
+			syms = append(syms, sym)
+		}
+	}
+	sort.Sort(bySymbolName(syms))
+	return syms
+}
+
+// VariableSymbols finds the package variables whose names match re.
+func VariableSymbols(re *regexp.Regexp) []Symbol {
+	return memberSymbols(re, token.VAR)
+}
+
+// TypeSymbols finds the named types whose names match re.
+func TypeSymbols(re *regexp.Regexp) []Symbol {
+	return memberSymbols(re, token.TYPE)
+}
+
+// LookupType finds the type typeName: T, *T, pkg.T or pkg/path.T.
+// Names without a package are looked up in the package of the current
+// frame.
+func LookupType(typeName string) (types.Type, error) {
+	name := strings.TrimPrefix(typeName, "*")
+	pkg := curFrame.Fn().Pkg
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if pkg = PackageByPathOrName(name[:i]); pkg == nil {
+			return nil, fmt.Errorf("can't find package %s", name[:i])
+		}
+		name = name[i+1:]
+	}
+	t := pkg.Type(name)
+	if t == nil {
+		return nil, fmt.Errorf("%s is not a type in package %s", name,
+			pkg.Object.Path())
+	}
+	if strings.HasPrefix(typeName, "*") {
+		return types.NewPointer(t.Type()), nil
+	}
+	return t.Type(), nil
+}
+
+// MethodSymbols finds the methods of type typ. For a named type that
+// isn't an interface, the methods of a pointer to it are included.
+// Promoted methods and those reached through an automatic pointer
+// dereference are synthetic wrappers.
+func MethodSymbols(typ types.Type) []Symbol {
+	_, isPtr := typ.(*types.Pointer)
+	_, isIface := typ.Underlying().(*types.Interface)
+	if !isPtr && !isIface { typ = types.NewPointer(typ) }
+	mset := program.MethodSets.MethodSet(typ)
+	var syms []Symbol
+	for i := 0; i < mset.Len(); i++ {
+		sel := mset.At(i)
+		if isIface {
+			obj := sel.Obj()
+			syms = append(syms, Symbol{Name: obj.Name(),
+				Desc: obj.Type().String() + " [abstract]",
+				Where: posRange(obj.Pos(), obj.Pos())})
+			continue
+		}
+		if fn := program.Method(sel); fn != nil {
+			syms = append(syms, fnSymbol(fn))
+		}
+	}
+	sort.Sort(bySymbolName(syms))
+	return syms
+}
@@ -0,0 +1,11 @@
+# Test of breakpoint locations: methods, file:line and rbreak
+# Use with method.go
+set highlight off
+break celsius.double
+break testdata/method.go:12
+# A breakpoint on each function matching a regular expression
+rbreak ^main\.ha
+continue
+continue
+continue
+quit
@@ -0,0 +1,8 @@
+# Test of "call" and function calls in expressions
+# Use with gcd.go
+set highlight off
+call gcd(12, 18)
+eval gcd(12, 18) + 1
+eval false && gcd(0, 1) == 1
+call gcd
+quit
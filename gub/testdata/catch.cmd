@@ -0,0 +1,8 @@
+# Test of catchpoints
+# Use with recover.go
+set highlight off
+catch panic
+catch recover
+continue
+continue
+quit
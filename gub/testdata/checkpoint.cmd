@@ -0,0 +1,12 @@
+# Test of "checkpoint" and "run" with a checkpoint
+# Use with watch.go
+set highlight off
+step
+checkpoint
+break 12
+continue
+eval count
+# Go back to the checkpoint; count is 0 again
+run 1
+eval count
+quit
@@ -0,0 +1,12 @@
+# Test of breakpoint command lists
+# Use with gcd.go
+set highlight off
+break gcd
+commands
+silent
+bt
+continue
+end
+break 17
+continue
+quit
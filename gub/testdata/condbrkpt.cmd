@@ -0,0 +1,12 @@
+# Test of conditional breakpoints and "condition"
+# Use with gcd.go
+set highlight off
+# Stop only in the recursive call gcd(2, 3)
+break gcd if a == 2
+continue
+bt
+# Now stop only in gcd(1, 2)
+condition  1  a == 1
+continue
+condition 1
+quit
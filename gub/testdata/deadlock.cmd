@@ -0,0 +1,9 @@
+# Test of stopping when the program deadlocks
+# Use with deadlock.go
+set highlight off
+continue
+# Each goroutine is shown with what it is blocked in
+goroutines
+# The program can't be resumed
+step
+quit
@@ -0,0 +1,13 @@
+package main
+
+var c = make(chan int)
+
+// worker waits for a value that is never sent.
+func worker() {
+	<-c
+}
+
+func main() {
+	go worker()
+	<-c
+}
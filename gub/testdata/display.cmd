@@ -0,0 +1,17 @@
+# Test of "display", "info display" and "undisplay"
+# Use with watch.go
+set highlight off
+step
+display count
+display count * 2
+set displaychanged on
+# n is a local of bump, shown only while stopped in it
+step
+display n
+info display
+break 12
+continue
+info display
+undisplay 2
+next
+quit
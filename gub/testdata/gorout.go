@@ -0,0 +1,15 @@
+package main
+
+var c = make(chan int)
+
+// worker sends the square of n on c.
+func worker(n int) {
+	c <- n * n
+}
+
+func main() {
+	go worker(2)
+	go worker(3)
+	<-c
+	<-c
+}
@@ -0,0 +1,10 @@
+# Test of goroutine-scoped breakpoints and "goroutine"
+# Use with gorout.go
+set highlight off
+# Stop only in the goroutine running worker(3)
+break worker goroutine 2
+continue
+goroutine
+goroutines 2
+goroutine 9
+quit
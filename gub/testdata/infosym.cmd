@@ -0,0 +1,11 @@
+# Test of "info functions", "info types", "info variables" and
+# "info methods"
+# Use with method.go
+set highlight off
+info functions ^main\.ha
+info types ^main\.
+info variables ^main\.b
+info variables ^main\.$
+# The method set of *celsius has a wrapper for double
+info methods celsius
+quit
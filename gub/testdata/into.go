@@ -0,0 +1,13 @@
+package main
+
+func square(n int) int {
+	return n * n
+}
+
+func add(a int, b int) int {
+	return a + b
+}
+
+func main() {
+	add(square(2), square(3))
+}
@@ -0,0 +1,10 @@
+# Test of "list"
+# Use with gcd.go
+set highlight off
+break gcd
+break 17
+disable 2
+list 14,18
+list
+list gcd
+quit
@@ -0,0 +1,8 @@
+# Test of logpoints
+# Use with gcd.go
+set highlight off
+logpoint gcd "gcd({a}, {b})"
+logpoint gcd "a is one" if a == 1
+break 17
+continue
+quit
@@ -0,0 +1,22 @@
+package main
+
+type celsius int
+
+// double gives twice t.
+func (t celsius) double() celsius {
+	return t * 2
+}
+
+// half gives half of t.
+func half(t celsius) celsius {
+	return t / 2
+}
+
+func main() {
+	t := celsius(10)
+	t = t.double()
+	t = half(t)
+}
+
+// boiling is where water boils.
+var boiling = celsius(100)
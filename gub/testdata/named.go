@@ -0,0 +1,12 @@
+package main
+
+// twice gives 2n, doubling r on the way out.
+func twice(n int) (r int) {
+	defer func() { r *= 2 }()
+	r = n
+	return r
+}
+
+func main() {
+	println(twice(5))
+}
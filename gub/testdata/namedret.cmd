@@ -0,0 +1,9 @@
+# Test of "return" with named results
+# Use with named.go
+set highlight off
+break twice
+continue
+next
+# r is set to 4, then the deferred call doubles it
+return 4
+quit
@@ -0,0 +1,11 @@
+# Test of "set nonstop" and "show nonstop"
+# Use with gorout.go
+set highlight off
+show nonstop
+set nonstop on
+show nonstop
+set nonstop off
+# All goroutines stop at the breakpoint
+break 13
+continue
+quit
@@ -0,0 +1,15 @@
+# Test of post-mortem debugging
+# Use with postmortem.go and -postmortem
+set highlight off
+continue
+# The panic stops first; the program dies after it
+continue
+# The panicking frame and its caller are still there
+bt
+# Expressions are evaluated in the panicking frame
+eval a
+eval a*10 + b
+# The program can't be resumed
+continue
+next
+quit
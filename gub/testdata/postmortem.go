@@ -0,0 +1,11 @@
+package main
+
+// ratio panics when b is 0.
+func ratio(a int, b int) int {
+	return a / b
+}
+
+func main() {
+	n := ratio(6, 0)
+	println(n)
+}
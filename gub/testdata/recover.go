@@ -0,0 +1,15 @@
+package main
+
+// safeDiv divides a by b, giving 0 if that panics.
+func safeDiv(a int, b int) (q int) {
+	defer func() {
+		if recover() != nil {
+			q = 0
+		}
+	}()
+	return a / b
+}
+
+func main() {
+	safeDiv(6, 0)
+}
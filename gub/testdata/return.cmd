@@ -0,0 +1,8 @@
+# Test of "return"
+# Use with gcd.go
+set highlight off
+break gcd
+continue
+return 1, 2
+return 9
+quit
@@ -0,0 +1,16 @@
+# Test of "record" and going back over the execution history
+# Use with gcd.go
+set highlight off
+record
+step
+step
+next
+next
+reverse-step
+reverse-step
+# We are at the start of the history now
+reverse-step
+# Go forward to where the program stopped
+continue
+record off
+quit
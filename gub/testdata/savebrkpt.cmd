@@ -0,0 +1,12 @@
+# Test of "save breakpoints" and "source"
+# Use with gcd.go
+set highlight off
+break gcd if a == 2
+break 17
+disable 2
+save breakpoints testdata/savebrkpt.sav
+delete 1 2
+# Read the breakpoints back in
+source testdata/savebrkpt.sav
+continue
+quit
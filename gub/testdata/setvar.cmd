@@ -0,0 +1,11 @@
+# Test of "set var"
+# Use with watch.go
+set highlight off
+step
+set var count = 40
+next
+eval count
+# A watchpoint sees changes made by "set var"
+watch count
+set var count = 1
+quit
@@ -0,0 +1,9 @@
+# Test of skipping functions when stepping
+# Use with into.go
+set highlight off
+skip function square
+step
+# Steps over both calls of square and into add
+step
+skip delete 1
+quit
@@ -0,0 +1,9 @@
+# Test of "step into"
+# Use with into.go
+set highlight off
+step
+step into square
+# Stop in the second call, square(3)
+step into 2
+finish
+quit
@@ -0,0 +1,13 @@
+# Test of ignore counts and temporary breakpoints
+# Use with gcd.go
+set highlight off
+break gcd
+# Pass over gcd(5, 3) and stop in gcd(2, 3)
+ignore 1 1
+continue
+delete 1
+tbreak 17
+continue
+# The temporary breakpoint is gone now
+delete 2
+quit
@@ -0,0 +1,8 @@
+# Test of step counts, "advance" and "until"
+# Use with gcd.go
+set highlight off
+step 2
+next 3
+advance 19
+until
+quit
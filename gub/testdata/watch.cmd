@@ -0,0 +1,8 @@
+# Test of watchpoints
+# Use with watch.go
+set highlight off
+watch count
+continue
+continue
+delete 1
+quit
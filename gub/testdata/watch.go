@@ -0,0 +1,13 @@
+package main
+
+var count int
+
+// bump adds n to count.
+func bump(n int) {
+	count += n
+}
+
+func main() {
+	bump(2)
+	bump(3)
+}
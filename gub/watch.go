@@ -0,0 +1,146 @@
+// Copyright 2015 Rocky Bernstein.
+// Watchpoints: stop when the value of some location changes.
+
+package gub
+
+import (
+	"fmt"
+	"go/parser"
+	"reflect"
+
+	"github.com/rocky/go-types"
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/ssa-interp/interp"
+)
+
+// WatchInfo is the part of a Breakpoint specific to watchpoints.
+type WatchInfo struct {
+	Expr  string        // The expression as the user gave it
+	Frame *interp.Frame // Frame of a watched local; nil otherwise
+	Type  types.Type    // Type of the watched location
+	read  watchRead
+	old   interp.Value  // snapshot of the value last seen
+	prev  interp.Value  // value before the change that stopped us
+}
+
+// watchRead is how we get the current value of a watched location.
+// The boolean is false if the location doesn't exist, e.g. a map
+// entry that hasn't been added yet.
+type watchRead func() (interp.Value, bool)
+
+// watchNotInMemory is the error for SSA values that can't be watched
+// because the interpreter never stores to them.
+func watchNotInMemory(name string) error {
+	return fmt.Errorf("%s is not stored in memory, so it can't change", name)
+}
+
+// WatchpointNew creates a watchpoint for exprStr in frame fr.
+func WatchpointNew(fr *interp.Frame, exprStr string) (*Breakpoint, error) {
+	expr, err := parser.ParseExpr(exprStr)
+	if err != nil { return nil, err }
+	lv, err := resolveLvalue(fr, expr)
+	if err != nil { return nil, err }
+	if lv.register { return nil, watchNotInMemory(exprStr) }
+	watch := &WatchInfo{
+		Expr: exprStr,
+		Type: lv.typ,
+		read: lv.read,
+	}
+	if lv.local { watch.Frame = fr }
+	if v, ok := lv.read(); ok {
+		watch.old = interp.CopyValDeep(v)
+	}
+	bp := &Breakpoint {
+		Hits: 0,
+		Id: BreakpointNext(),
+		Ignore: 0,
+		Kind: "Watchpoint",
+		Temp: false,
+		Enabled: true,
+		Watch: watch,
+		GoNum: -1,
+	}
+	WatchpointAdd(bp)
+	interp.SetWriteHook(watchHook)
+	return bp, nil
+}
+
+// watchpointsLeft reports whether any watchpoint is still set.
+func watchpointsLeft() bool {
+	for _, bp := range Breakpoints {
+		if bp.Watch != nil && !bp.Deleted { return true }
+	}
+	return false
+}
+
+// watchFormat shows a watched value, or that it doesn't exist.
+func watchFormat(v interp.Value, typ types.Type) string {
+	if v == nil { return "<not set>" }
+	return interp.ToInspectType(v, typ)
+}
+
+// curWatch is the watchpoint that triggered the current stop, if any.
+var curWatch *Breakpoint
+
+// watchTriggered refreshes the snapshot of every active watchpoint
+// and returns the first one whose value changed and which should
+// stop the program.
+func watchTriggered(fr *interp.Frame) *Breakpoint {
+	var hit *Breakpoint
+	for _, bp := range Breakpoints {
+		watch := bp.Watch
+		if watch == nil || bp.Deleted || !bp.Enabled { continue }
+		if watch.Frame != nil && watch.Frame.Status() != interp.StRunning {
+			Msg("Watchpoint %d deleted because the program has left the "+
+				"function in which its expression is valid.", bp.Id)
+			BreakpointDelete(bp.Id)
+			continue
+		}
+		v, _ := watch.read()
+		if reflect.DeepEqual(v, watch.old) { continue }
+		prev := watch.old
+		watch.old = interp.CopyValDeep(v)
+		if hit != nil || !BreakpointCondTrue(bp, fr) { continue }
+		bp.Hits++
+		if bp.Ignore > 0 {
+			bp.Ignore--
+			continue
+		}
+		watch.prev = prev
+		hit = bp
+	}
+	return hit
+}
+
+// watchHook is the interpreter's write hook while there are
+// watchpoints. It stops in the debugger when a watched value changes.
+func watchHook(fr *interp.Frame, instr ssa2.Instruction) {
+	if instr == nil {
+		// A write made by a debugger command, such as "set var".
+		// We are already stopped, so just say what changed.
+		if bp := watchTriggered(fr); bp != nil { printWatchChange(bp) }
+		return
+	}
+	// In a function called from the debugger, we already hold gubLock.
+	nested := inDebuggerCall(fr)
+	if nested && !CallStop { return }
+	if !nested {
+		interp.Park(fr)
+		gubLock.Lock()
+		interp.Unpark(fr)
+	}
+	bp := watchTriggered(fr)
+	if bp != nil { curWatch = bp }
+	if !nested { gubLock.Unlock() }
+	if bp != nil {
+		GubTraceHook(fr, &instr, ssa2.WATCHPOINT)
+	}
+}
+
+// printWatchChange shows the change that triggered watchpoint bp.
+func printWatchChange(bp *Breakpoint) {
+	watch := bp.Watch
+	Msg("Watchpoint %d: %s", bp.Id, watch.Expr)
+	Msg("Old value = %s", watchFormat(watch.prev, watch.Type))
+	Msg("New value = %s", watchFormat(watch.old, watch.Type))
+}
@@ -0,0 +1,110 @@
+// Copyright 2015 Rocky Bernstein.
+
+/*
+This file lets the debugger stop the whole interpreted program while
+it has one goroutine stopped: "all-stop" mode.
+
+Interpreted goroutines run on goroutines of the host, so the
+debugger can't suspend them directly. Instead, each goroutine checks
+before every instruction whether the world is stopped and, if so,
+parks until it is started again. The goroutine the debugger stopped
+in is exempt, so functions called from the debugger can run.
+
+StopTheWorld returns once every other goroutine is parked, blocked in
+a channel operation, a select or a lock, or waiting to get into the
+debugger; none of these can change the state of the program. A
+goroutine in an external function, for example time.Sleep, is
+waited for until it returns and parks.
+*/
+package interp
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// worldStopped is 1 while the world is stopped. It is read without
+// the lock on each instruction, so is kept separately from the rest.
+var worldStopped int32
+
+var worldLock sync.Mutex
+var worldStarted = sync.NewCond(&worldLock)
+
+// worldOwner is the goroutine that stopped the world.
+var worldOwner int
+
+// worldQuiet is signalled, under waitLock, when a goroutine stops
+// running: it parks, blocks or finishes.
+var worldQuiet = sync.NewCond(&waitLock)
+
+// othersRunning reports whether a goroutine other than goNum is
+// running. waitLock must be held.
+func othersRunning(goNum int) bool {
+	for n, g := range i.goTops {
+		if n == goNum || g.finished || g.parked || g.waitReason != "" {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// StopTheWorld makes every goroutine other than goNum wait at its
+// next instruction until StartTheWorld is called. It returns once
+// none of them is running.
+func StopTheWorld(goNum int) {
+	worldLock.Lock()
+	worldOwner = goNum
+	atomic.StoreInt32(&worldStopped, 1)
+	worldLock.Unlock()
+	if sched != nil {
+		// Only the goroutine holding the baton runs, and that's us.
+		return
+	}
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	for othersRunning(goNum) {
+		worldQuiet.Wait()
+	}
+}
+
+// StartTheWorld lets the goroutines held by StopTheWorld run again.
+func StartTheWorld() {
+	worldLock.Lock()
+	defer worldLock.Unlock()
+	atomic.StoreInt32(&worldStopped, 0)
+	worldStarted.Broadcast()
+}
+
+// WorldStopped reports whether the world is stopped.
+func WorldStopped() bool {
+	return atomic.LoadInt32(&worldStopped) != 0
+}
+
+// setParked marks the goroutine of frame fr as parked, or not.
+func setParked(fr *Frame, parked bool) {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	fr.i.goTops[fr.goNum].parked = parked
+	if parked { worldQuiet.Broadcast() }
+}
+
+// Park marks the goroutine of frame fr as not running while it waits
+// to get into the debugger, so that StopTheWorld doesn't wait for it.
+// Unpark undoes this.
+func Park(fr *Frame)   { setParked(fr, true) }
+func Unpark(fr *Frame) { setParked(fr, false) }
+
+// parkIfStopped makes frame fr wait while the world is stopped by
+// another goroutine.
+func parkIfStopped(fr *Frame) {
+	if atomic.LoadInt32(&worldStopped) == 0 { return }
+	worldLock.Lock()
+	defer worldLock.Unlock()
+	if atomic.LoadInt32(&worldStopped) == 0 || fr.goNum == worldOwner { return }
+	setParked(fr, true)
+	for atomic.LoadInt32(&worldStopped) != 0 && fr.goNum != worldOwner {
+		worldStarted.Wait()
+	}
+	setParked(fr, false)
+}
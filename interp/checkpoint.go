@@ -0,0 +1,372 @@
+// Copyright 2015 Rocky Bernstein.
+
+/*
+This file saves and restores checkpoints: snapshots of the state of
+the interpreted program taken when it is stopped in the debugger.
+
+A checkpoint saves the contents of every variable reachable from the
+globals and from the frames of every goroutine. Variables are saved by
+address, so restoring writes the saved contents back in place and
+pointers held by the program stay valid.
+
+Since interpreted calls run on the Go stack, we can only resume at a
+checkpoint whose frame is still running in the goroutine that is
+stopped. Frames called since then are abandoned, without running
+their deferred calls. Other goroutines get their variables back but
+keep running where they are. Channel contents are not saved.
+*/
+package interp
+
+import (
+	"errors"
+	"go/token"
+	"reflect"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A Checkpoint is a snapshot of the program's state from which it can
+// be resumed.
+type Checkpoint struct {
+	Event  ssa2.TraceEvent // event that stopped the program
+	Pos    token.Pos       // start of the statement we stopped at
+	fr     *Frame
+	pc     int             // instruction we stopped at
+	next   int             // instruction to resume with
+	state  frameSnap
+	chains [][]*Frame      // running frames of each goroutine, innermost first
+	mem    snapshot
+}
+
+// Frame is the frame the checkpoint was taken in.
+func (cp *Checkpoint) Frame() *Frame { return cp.fr }
+
+// Goroutines is the number of goroutines that were running when the
+// checkpoint was taken.
+func (cp *Checkpoint) Goroutines() int {
+	n := 0
+	for _, chain := range cp.chains {
+		if len(chain) > 0 { n++ }
+	}
+	return n
+}
+
+// Variables is the number of variables saved in the checkpoint.
+func (cp *Checkpoint) Variables() int {
+	return len(cp.mem.cells) + len(cp.mem.slices) + len(cp.mem.maps)
+}
+
+// frameSnap is the part of a frame that a checkpoint restores.
+type frameSnap struct {
+	frameState
+	prevBlock *ssa2.BasicBlock
+	defers    []func()
+	tracing   TraceType
+}
+
+// snapshot holds the saved contents of the program's variables.
+type snapshot struct {
+	cells  map[*Value]Value       // variables, by address
+	slices map[*Value]savedSlice  // slice backing arrays, by first element
+	maps   map[uintptr]savedMap   // maps, by identity
+}
+
+type savedSlice struct {
+	all   []Value // the whole backing array
+	saved []Value
+}
+
+type savedMap struct {
+	m       Value
+	entries map[Value]Value // for a map[Value]Value
+	table   map[int]*entry  // for a *hashmap
+	length  int
+}
+
+// snapCopy copies v the way copyVal does, but all the way through
+// nested structs and arrays, which are values and so don't share
+// memory. References are kept; what they refer to is saved
+// separately.
+func snapCopy(v Value) Value {
+	switch v := v.(type) {
+	case Structure:
+		a := Structure{
+			fields    : make([]Value, len(v.fields)),
+			fieldnames: v.fieldnames,
+		}
+		for i, e := range v.fields {
+			a.fields[i] = snapCopy(e)
+		}
+		return a
+	case array:
+		a := make(array, len(v))
+		for i, e := range v {
+			a[i] = snapCopy(e)
+		}
+		return a
+	case tuple:
+		a := make(tuple, len(v))
+		for i, e := range v {
+			a[i] = snapCopy(e)
+		}
+		return a
+	case iface:
+		return iface{t: v.t, v: snapCopy(v.v)}
+	}
+	return v
+}
+
+// restoreInto writes saved back into *addr. Structs and arrays of the
+// same shape are written element by element so that pointers to their
+// fields and elements stay valid.
+func restoreInto(addr *Value, saved Value) {
+	switch s := saved.(type) {
+	case Structure:
+		if d, ok := (*addr).(Structure); ok && len(d.fields) == len(s.fields) {
+			for i := range s.fields {
+				restoreInto(&d.fields[i], s.fields[i])
+			}
+			return
+		}
+	case array:
+		if d, ok := (*addr).(array); ok && len(d) == len(s) {
+			for i := range s {
+				restoreInto(&d[i], s[i])
+			}
+			return
+		}
+	}
+	*addr = snapCopy(saved)
+}
+
+// save adds v, and everything reachable from it, to s.
+func (s *snapshot) save(v Value) {
+	switch v := v.(type) {
+	case *Value:
+		if v == nil { return }
+		if _, seen := s.cells[v]; seen { return }
+		s.cells[v] = snapCopy(*v)
+		s.save(*v)
+	case Structure:
+		for _, e := range v.fields {
+			s.save(e)
+		}
+	case array:
+		for _, e := range v {
+			s.save(e)
+		}
+	case tuple:
+		for _, e := range v {
+			s.save(e)
+		}
+	case iface:
+		s.save(v.v)
+	case *closure:
+		for _, e := range v.Env {
+			s.save(e)
+		}
+	case []Value:
+		if cap(v) == 0 { return }
+		all := v[:cap(v)]
+		if _, seen := s.slices[&all[0]]; seen { return }
+		saved := make([]Value, len(all))
+		for i, e := range all {
+			saved[i] = snapCopy(e)
+		}
+		s.slices[&all[0]] = savedSlice{all: all, saved: saved}
+		for _, e := range all {
+			s.save(e)
+		}
+	case map[Value]Value:
+		if v == nil { return }
+		id := reflect.ValueOf(v).Pointer()
+		if _, seen := s.maps[id]; seen { return }
+		entries := make(map[Value]Value, len(v))
+		for k, e := range v {
+			entries[k] = snapCopy(e)
+		}
+		s.maps[id] = savedMap{m: v, entries: entries}
+		for k, e := range v {
+			s.save(k)
+			s.save(e)
+		}
+	case *hashmap:
+		if v == nil { return }
+		id := reflect.ValueOf(v).Pointer()
+		if _, seen := s.maps[id]; seen { return }
+		table := make(map[int]*entry, len(v.table))
+		for h, e := range v.table {
+			table[h] = copyEntries(e)
+		}
+		s.maps[id] = savedMap{m: v, table: table, length: v.length}
+		for _, e := range v.table {
+			for ; e != nil; e = e.next {
+				s.save(e.key)
+				s.save(e.Value)
+			}
+		}
+	}
+}
+
+// copyEntries copies a hash chain of a *hashmap.
+func copyEntries(e *entry) *entry {
+	if e == nil { return nil }
+	return &entry{key: e.key, Value: snapCopy(e.Value), next: copyEntries(e.next)}
+}
+
+// restore writes everything saved in s back.
+func (s *snapshot) restore() {
+	for addr, saved := range s.cells {
+		restoreInto(addr, saved)
+	}
+	for _, sl := range s.slices {
+		for i := range sl.saved {
+			restoreInto(&sl.all[i], sl.saved[i])
+		}
+	}
+	for _, saved := range s.maps {
+		switch m := saved.m.(type) {
+		case map[Value]Value:
+			for k := range m {
+				delete(m, k)
+			}
+			for k, e := range saved.entries {
+				m[k] = snapCopy(e)
+			}
+		case *hashmap:
+			m.table = make(map[int]*entry, len(saved.table))
+			for h, e := range saved.table {
+				m.table[h] = copyEntries(e)
+			}
+			m.length = saved.length
+		}
+	}
+}
+
+// runningChain gives the frames of goroutine goNum that are still
+// running, innermost first.
+func (i *interpreter) runningChain(goNum int) []*Frame {
+	var chain []*Frame
+	fr := i.goTop(goNum).Fr
+	for fr != nil && fr.status != StRunning {
+		fr = fr.caller
+	}
+	for ; fr != nil; fr = fr.caller {
+		chain = append(chain, fr)
+	}
+	return chain
+}
+
+// NewCheckpoint takes a checkpoint of the program, which is stopped
+// in frame fr at instruction instr because of event.
+func NewCheckpoint(fr *Frame, instr ssa2.Instruction, event ssa2.TraceEvent) (*Checkpoint, error) {
+	if fr == nil || fr.block == nil || fr.status != StRunning || fr.panicking {
+		return nil, errors.New("the program isn't running at a place it can be resumed from")
+	}
+	cp := &Checkpoint{Event: event, Pos: fr.startP, fr: fr, pc: fr.pc}
+	switch {
+	case fr.pc == 0 && fr.startP == fr.fn.Pos() &&
+		(event == ssa2.CALL_ENTER || event == ssa2.BREAKPOINT):
+		// Function entry, before the first instruction has run.
+		cp.next = 0
+	default:
+		if _, ok := instr.(*ssa2.Trace); !ok || fr.block.Instrs[fr.pc] != instr {
+			return nil, errors.New("can only checkpoint at a statement or function entry")
+		}
+		cp.next = fr.pc + 1
+	}
+	cp.state = frameSnap{
+		frameState: saveFrameState(fr),
+		prevBlock:  fr.prevBlock,
+		defers:     append([]func(){}, fr.defers...),
+		tracing:    fr.tracing,
+	}
+	env := make(map[ssa2.Value]Value, len(fr.env))
+	for k, v := range fr.env {
+		env[k] = snapCopy(v)
+	}
+	cp.state.env = env
+
+	i := fr.i
+	cp.mem = snapshot{
+		cells:  make(map[*Value]Value),
+		slices: make(map[*Value]savedSlice),
+		maps:   make(map[uintptr]savedMap),
+	}
+	for _, g := range i.globals {
+		cp.mem.save(g)
+	}
+	gocall.Lock()
+	goTops := i.goTops
+	gocall.Unlock()
+	for goNum := range goTops {
+		chain := i.runningChain(goNum)
+		cp.chains = append(cp.chains, chain)
+		for _, f := range chain {
+			for _, v := range f.env {
+				cp.mem.save(v)
+			}
+			for k := range f.locals {
+				cp.mem.save(&f.locals[k])
+			}
+		}
+	}
+	return cp, nil
+}
+
+// restartPanic unwinds the interpreter's Go stack to the frame of a
+// checkpoint being restarted.
+type restartPanic struct {
+	cp *Checkpoint
+}
+
+// RestoreCheckpoint puts back the state saved in cp, for resuming
+// from goroutine frame fr, the innermost frame of the goroutine that
+// is stopped. It returns the numbers of the goroutines that have moved
+// on since the checkpoint and so are not rewound. On success,
+// ResumeCheckpoint must be called next.
+func RestoreCheckpoint(fr *Frame, cp *Checkpoint) ([]int, error) {
+	if fr.i.Mode&DisableRecover != 0 {
+		return nil, errors.New("can't restart a checkpoint when recover() is disabled")
+	}
+	if cp.fr.goNum != fr.goNum {
+		return nil, errors.New("checkpoint is in a different goroutine")
+	}
+	found := false
+	for f := fr; f != nil; f = f.caller {
+		if f == cp.fr { found = f.status == StRunning }
+	}
+	if !found {
+		return nil, errors.New("the function the checkpoint was taken in has returned")
+	}
+	cp.mem.restore()
+	s := cp.state
+	s.restore()
+	cp.fr.prevBlock = s.prevBlock
+	cp.fr.defers = append([]func(){}, s.defers...)
+	cp.fr.tracing = s.tracing
+	env := make(map[ssa2.Value]Value, len(s.env))
+	for k, v := range s.env {
+		env[k] = snapCopy(v)
+	}
+	cp.fr.env = env
+
+	var moved []int
+	for goNum, chain := range cp.chains {
+		if goNum == fr.goNum || len(chain) == 0 { continue }
+		now := fr.i.runningChain(goNum)
+		if len(now) != len(chain) || (len(now) > 0 && now[0] != chain[0]) {
+			moved = append(moved, goNum)
+		}
+	}
+	return moved, nil
+}
+
+// ResumeCheckpoint unwinds the stack to the frame of checkpoint cp,
+// whose state RestoreCheckpoint has put back, and resumes running it
+// there. The first thing run is TraceHook for the event that stopped
+// the program when the checkpoint was taken. ResumeCheckpoint doesn't
+// return.
+func ResumeCheckpoint(cp *Checkpoint) {
+	panic(restartPanic{cp})
+}
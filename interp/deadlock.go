@@ -0,0 +1,311 @@
+// Copyright 2015 Rocky Bernstein.
+
+/*
+This file detects when the interpreted program is deadlocked: every
+goroutine is blocked in a channel operation, a select without a
+default or a semaphore acquire of package sync, so none can go on.
+
+Under the deterministic scheduler this is known exactly. Otherwise
+goroutines block in operations on channels of the host, and a
+goroutine counts as blocked from just before such an operation until
+just after it. Two goroutines can count as blocked on either end of
+the same channel for an instant before the host pairs them up, so all
+goroutines seeming blocked is only taken as a deadlock if it lasts,
+with no operation finishing, for deadlockDelay.
+
+A deadlock is reported the way Go reports one, followed by the
+interpreted stack of each goroutine and what it is blocked in. In the
+debugger, the program then stops with a DEADLOCK event; it can't be
+resumed. The debugger is only ever entered by a goroutine in its own
+frames, so the deadlock is handed to one of the blocked goroutines,
+the first in goroutine order, which is woken to report it.
+*/
+package interp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// waitLock protects the counts below and the waitReason of each
+// goroutine. The goTops slice of the interpreter is appended to
+// holding both gocall and waitLock, so it can be read holding either.
+var waitLock sync.Mutex
+
+var nLive int      // goroutines started and not yet finished
+var nWaiting int   // goroutines blocked
+var waitGen uint64 // incremented whenever a blocked operation finishes
+var watching bool  // set once watchDeadlock is running
+
+const deadlockDelay = 100 * time.Millisecond
+
+// resetWaits starts the counts for a run of the program, which has
+// just its main goroutine.
+func resetWaits() {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	nLive, nWaiting = 1, 0
+}
+
+// goTop gives the state of goroutine goNum. waitLock must not be
+// held; code holding it indexes goTops directly.
+func (i *interpreter) goTop(goNum int) *GoreState {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	return i.goTops[goNum]
+}
+
+// allGoTops gives the state of each goroutine started so far.
+// waitLock must not be held.
+func (i *interpreter) allGoTops() []*GoreState {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	return i.goTops
+}
+
+func goStarted() {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	nLive++
+}
+
+// goFinished marks goroutine goNum as having returned.
+func (i *interpreter) goFinished(goNum int) {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	nLive--
+	i.goTops[goNum].finished = true
+	worldQuiet.Broadcast()
+}
+
+// WaitReason gives the operation the goroutine is blocked in, as Go
+// describes it in a stack dump, or "" if it isn't blocked.
+func (g *GoreState) WaitReason() string {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	return g.waitReason
+}
+
+// waitStart marks the goroutine of frame fr as blocked in the
+// operation described by reason.
+func waitStart(fr *Frame, reason string) {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	fr.i.goTops[fr.goNum].waitReason = reason
+	nWaiting++
+	worldQuiet.Broadcast()
+	if sched == nil && !watching {
+		watching = true
+		go watchDeadlock()
+	}
+}
+
+// waitEnd marks the goroutine of frame fr as no longer blocked.
+func waitEnd(fr *Frame) {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	fr.i.goTops[fr.goNum].waitReason = ""
+	nWaiting--
+	waitGen++
+}
+
+// chanWaitReason describes a blocked send, or receive, on ch.
+func chanWaitReason(ch chan Value, send bool) string {
+	reason := "chan receive"
+	if send { reason = "chan send" }
+	if ch == nil { reason += " (nil chan)" }
+	return reason
+}
+
+// selectWaitReason describes a blocked select of n cases.
+func selectWaitReason(n int) string {
+	if n == 0 { return "select (no cases)" }
+	return "select"
+}
+
+// watchDeadlock looks for all goroutines staying blocked when they
+// block on channels of the host.
+func watchDeadlock() {
+	for {
+		time.Sleep(deadlockDelay)
+		waitLock.Lock()
+		stuck := nLive > 0 && nWaiting == nLive
+		gen := waitGen
+		waitLock.Unlock()
+		if !stuck { continue }
+		time.Sleep(deadlockDelay)
+		waitLock.Lock()
+		stuck = nLive > 0 && nWaiting == nLive && waitGen == gen
+		waitLock.Unlock()
+		if stuck {
+			i.handDeadlock()
+			return
+		}
+	}
+}
+
+// deadlocked is closed when watchDeadlock finds a deadlock, waking the
+// goroutines blocked on channels of the host. deadlockGo is the one
+// that reports it.
+var deadlocked = make(chan struct{})
+var deadlockGo int
+
+// isDeadlocked reports whether watchDeadlock has found a deadlock.
+func isDeadlocked() bool {
+	select {
+	case <-deadlocked:
+		return true
+	default:
+		return false
+	}
+}
+
+// handDeadlock hands the deadlock watchDeadlock found to the first
+// blocked goroutine.
+func (i *interpreter) handDeadlock() {
+	deadlockGo = -1
+	for goNum, g := range i.allGoTops() {
+		if g.WaitReason() != "" && len(i.runningChain(goNum)) > 0 {
+			deadlockGo = goNum
+			break
+		}
+	}
+	if deadlockGo < 0 { i.deadlock(nil) }
+	close(deadlocked)
+	semLock.Lock()
+	semReleased.Broadcast()
+	semLock.Unlock()
+}
+
+// deadlockWake is called in frame fr, whose goroutine has been woken
+// from a blocked operation on channels of the host because of a
+// deadlock. The goroutine the deadlock was handed to reports it; the
+// others stay blocked. It doesn't return.
+func deadlockWake(fr *Frame) {
+	if fr.goNum == deadlockGo { fr.i.deadlock(fr) }
+	select {}
+}
+
+// deadlock reports that the program is deadlocked and ends it. The
+// debugger is entered first in frame stopFr, the innermost frame of the
+// goroutine calling, which is blocked; stopFr is nil if there is none.
+func (i *interpreter) deadlock(stopFr *Frame) {
+	fmt.Fprintln(os.Stderr, "fatal error: all goroutines are asleep - deadlock!")
+	for goNum := range i.allGoTops() {
+		if len(i.runningChain(goNum)) == 0 { continue }
+		fmt.Fprintln(os.Stderr)
+		i.writeGoroutine(os.Stderr, goNum)
+	}
+	if stopFr != nil {
+		TraceHook(stopFr, nil, ssa2.DEADLOCK)
+		TraceHook(stopFr, nil, ssa2.PROGRAM_TERMINATION)
+	}
+	os.Exit(2)
+}
+
+// writeGoroutine writes to w what goroutine goNum is blocked in, its
+// interpreted stack and where it was started, much as Go does in a
+// stack dump.
+func (i *interpreter) writeGoroutine(w io.Writer, goNum int) {
+	g := i.goTop(goNum)
+	reason := g.WaitReason()
+	if reason == "" { reason = "running" }
+	fmt.Fprintf(w, "goroutine %d [%s]:\n", goNum, reason)
+	for _, fr := range i.runningChain(goNum) {
+		fmt.Fprintf(w, "%s\n\t%s\n", fr.FnAndParamString(), fr.PositionRange())
+	}
+	if g.startFn != nil {
+		fmt.Fprintf(w, "created by %s\n\t%s\n", g.startFn,
+			i.prog.Fset.Position(g.startPos))
+	}
+}
+
+// hostSend sends v on host channel ch for frame fr.
+func hostSend(fr *Frame, ch chan Value, v Value) {
+	select {
+	case ch <- v:
+		return
+	default:
+	}
+	waitStart(fr, chanWaitReason(ch, true))
+	defer waitEnd(fr)
+	select {
+	case ch <- v:
+	case <-deadlocked:
+		deadlockWake(fr)
+	}
+}
+
+// hostRecv receives from host channel ch for frame fr.
+func hostRecv(fr *Frame, ch chan Value) (Value, bool) {
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	default:
+	}
+	waitStart(fr, chanWaitReason(ch, false))
+	defer waitEnd(fr)
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-deadlocked:
+		deadlockWake(fr)
+	}
+	return nil, false
+}
+
+// hostSelect carries out a select without a default on host
+// channels for frame fr.
+func hostSelect(fr *Frame, cases []reflect.SelectCase) (int, reflect.Value, bool) {
+	poll := append(cases[:len(cases):len(cases)],
+		reflect.SelectCase{Dir: reflect.SelectDefault})
+	if chosen, recv, ok := reflect.Select(poll); chosen < len(cases) {
+		return chosen, recv, ok
+	}
+	waitStart(fr, selectWaitReason(len(cases)))
+	defer waitEnd(fr)
+	wake := append(cases[:len(cases):len(cases)],
+		reflect.SelectCase{Dir: reflect.SelectRecv,
+			Chan: reflect.ValueOf(deadlocked)})
+	chosen, recv, ok := reflect.Select(wake)
+	if chosen == len(cases) { deadlockWake(fr) }
+	return chosen, recv, ok
+}
+
+// semLock and semReleased implement the semaphores of package sync
+// outside of the deterministic scheduler.
+var semLock sync.Mutex
+var semReleased = sync.NewCond(&semLock)
+
+// hostSemacquire waits until *addr is positive and decrements it.
+func hostSemacquire(fr *Frame, addr *Value) {
+	semLock.Lock()
+	defer semLock.Unlock()
+	if (*addr).(uint32) == 0 {
+		waitStart(fr, "semacquire")
+		for (*addr).(uint32) == 0 {
+			semReleased.Wait()
+			if isDeadlocked() {
+				semLock.Unlock()
+				deadlockWake(fr)
+			}
+		}
+		waitEnd(fr)
+	}
+	*addr = (*addr).(uint32) - 1
+}
+
+// hostSemrelease increments *addr, waking any goroutines waiting for
+// it in hostSemacquire.
+func hostSemrelease(addr *Value) {
+	semLock.Lock()
+	defer semLock.Unlock()
+	*addr = (*addr).(uint32) + 1
+	semReleased.Broadcast()
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/rocky/eval"
 	"github.com/rocky/ssa-interp"
 	"reflect"
-	"fmt"
 )
 
 
@@ -60,18 +59,52 @@ func MakeEnv(static *eval.SimpleEnv, prog *ssa2.Program, fr *Frame) *EvalEnv {
 
 func (env EvalEnv) Static() eval.SimpleEnv { return *env.static }
 
+// interp2reflectPtr is like interp2reflectVal, but returns a pointer
+// to a copy of the value. eval wants variables handed back that way.
+func interp2reflectPtr(interpVal Value) reflect.Value {
+	v := interp2reflectVal(interpVal)
+	if !v.IsValid() { return reflectNil }
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
+
+// local looks up name as a local variable in the frame's scope
+// chain, and failing that as a parameter or free variable of the
+// frame's function.
 func (env EvalEnv) local(name string) (reflect.Value, bool) {
-	nameScope := ssa2.NameScope{
-		Name: name,
-		Scope: env.scope,
+	fn := env.curFn
+	for scope := env.scope; scope != nil; scope = ssa2.ParentScope(fn, scope) {
+		nameScope := ssa2.NameScope{
+			Name: name,
+			Scope: scope,
+		}
+		if i := fn.LocalsByName[nameScope]; i > 0 {
+			return interp2reflectPtr(env.frame.Local(i-1)), true
+		}
 	}
-	if i := env.curFn.LocalsByName[nameScope]; i == 0 {
-		return reflectNil, false
-	} else {
-		fmt.Printf("Got value %v %T local %s\n", env.frame.Local(i-1),
-			env.frame.Local(i-1), name)
-		return interp2reflectVal(env.frame.Local(i-1)), true
+	for _, p := range fn.Params {
+		if p.Name() == name {
+			return interp2reflectPtr(env.frame.env[p]), true
+		}
+	}
+	for _, fv := range fn.FreeVars {
+		if fv.Name() == name {
+			return interp2reflectPtr(env.frame.env[fv]), true
+		}
+	}
+	return reflectNil, false
+}
+
+// global looks up name as a package-level variable of the package
+// we are currently evaluating in.
+func (env EvalEnv) global(name string) (reflect.Value, bool) {
+	pkg := env.curPkg
+	if pkg == nil { return reflectNil, false }
+	if g, ok := env.frame.i.Global(name, pkg); ok && g != nil {
+		return interp2reflectPtr(*g), true
 	}
+	return reflectNil, false
 }
 
 
@@ -80,33 +113,46 @@ func (env EvalEnv) local(name string) (reflect.Value, bool) {
 func (env EvalEnv) Var(name string) reflect.Value {
 	if val, ok := env.local(name); ok {
 		return val
+	} else if val, ok := env.global(name); ok {
+		return val
+	} else if val, ok := env.static.Vars[name]; ok {
+		return val
 	} else {
 		return reflectNil
 	}
 }
 
+// Bind makes name a variable with value v for expressions evaluated
+// in env. The debugger uses it for the results of function calls it
+// has made.
+func (env EvalEnv) Bind(name string, v Value) {
+	env.static.Vars[name] = interp2reflectPtr(v)
+}
+
 func (env EvalEnv) Func(name string) reflect.Value {
 	pkg := env.curPkg
 	if pkg == nil { return reflect.Value{} }
-	return interp2reflectVal(pkg.Func(name))
+	if fn := pkg.Func(name); fn != nil {
+		return interp2reflectVal(fn)
+	}
+	return reflect.Value{}
 }
 
 func (env EvalEnv) Const(name string) reflect.Value {
 	pkg := env.curPkg
-	if pkg == nil {
-		fmt.Printf("const %s not found in package %s\n", name, pkg)
-		return reflect.Value{}
+	if pkg == nil { return reflect.Value{} }
+	if c := pkg.Const(name); c != nil {
+		return interp2reflectVal(constValue(c.Value))
 	}
-	return interp2reflectVal(pkg.Const(name))
+	return reflect.Value{}
 }
 
 func (env EvalEnv) Type(name string) reflect.Type {
-	fmt.Println("Looking up type for var %s", name)
-	if val, ok := env.local(name); ok {
-		return reflect.TypeOf(val)
-	} else {
-		return env.static.Type(name)
+	if _, ok := env.local(name); ok {
+		// A variable name shadows any type of the same name.
+		return nil
 	}
+	return env.static.Type(name)
 }
 
 func (env EvalEnv) Pkg(name string) eval.Env {
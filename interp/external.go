@@ -242,12 +242,20 @@ func ext۰sync۰runtime_registerPoolCleanup(fr *Frame, args []Value) Value {
 }
 
 func ext۰sync۰runtime_Semacquire(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: implement.
+	if sched != nil {
+		sched.semacquire(fr, args[0].(*Value))
+	} else {
+		hostSemacquire(fr, args[0].(*Value))
+	}
 	return nil
 }
 
 func ext۰sync۰runtime_Semrelease(fr *Frame, args []Value) Value {
-	// TODO(adonovan): fix: implement.
+	if sched != nil {
+		sched.semrelease(fr, args[0].(*Value))
+	} else {
+		hostSemrelease(args[0].(*Value))
+	}
 	return nil
 }
 
@@ -269,6 +277,10 @@ func ext۰runtime۰GC(fr *Frame, args []Value) Value {
 }
 
 func ext۰runtime۰Gosched(fr *Frame, args []Value) Value {
+	if sched != nil {
+		sched.yield(fr)
+		return nil
+	}
 	runtime.Gosched()
 	return nil
 }
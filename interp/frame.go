@@ -31,6 +31,14 @@ type Frame struct {
 	result           Value
 	panicking        bool
 	panic            interface{}
+	panicReported    bool        // PANIC event already raised for the
+	                             // panic unwinding through this frame
+	startedGoNum     int         // goroutine last started by a "go"
+	                             // statement in this frame
+	recorded         bool        // set once the frame is in the
+	                             // execution history
+	restart          *Checkpoint // checkpoint to resume at in the
+	                             // next runFrame
 
 	status           RunStatusType
 	tracing		     TraceType
@@ -123,6 +131,8 @@ func (fr *Frame) runDefers() {
 	}
 	fr.defers = nil
 	if fr.panicking {
+		// The caller sees the same panic; it has been reported here.
+		if fr.caller != nil { fr.caller.panicReported = true }
 		panic(fr.panic) // new panic, or still panicking
 	}
 }
@@ -166,4 +176,5 @@ func (fr *Frame) PrevBlock() *ssa2.BasicBlock { return fr.prevBlock }
 func (fr *Frame) Result() Value { return fr.result }
 func (fr *Frame) SetPC(newpc int) { fr.pc = newpc }
 func (fr *Frame) StartP() token.Pos { return fr.startP }
+func (fr *Frame) StartedGoNum() int { return fr.startedGoNum }
 func (fr *Frame) Status() RunStatusType { return fr.status }
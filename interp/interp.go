@@ -70,6 +70,19 @@ type Mode uint
 const (
 	// Disable recover() in target programs; show interpreter crash instead.
 	DisableRecover Mode = 1 << iota
+
+	// Enter the debugger at the panicking frame when the target
+	// program dies from a panic.
+	EnablePostMortem
+
+	// Run goroutines one at a time, switching between them at
+	// channel operations and other scheduling points as chosen from
+	// the seed given to SetScheduleSeed. See sched.go.
+	Deterministic
+
+	// Report goroutines still alive when main.main returns, and
+	// make the exit code LeakExitCode if there are any. See leak.go.
+	EnableLeakCheck
 )
 
 type methodSet map[string]*ssa2.Function
@@ -91,7 +104,8 @@ type interpreter struct {
 	TraceMode      TraceMode                 // interpreter trace options
 	TraceEventMask ssa2.TraceEventMask
 	nGoroutines    int                       // number of goroutines
-	goTops         []*GoreState
+	goTops         []*GoreState              // see waitLock
+	leaks          []int                     // goroutines alive when main.main returned
 }
 
 // runDefer runs a deferred call d.
@@ -147,7 +161,11 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 			}
 		}
 	case *ssa2.UnOp:
-		fr.env[instr] = unop(instr, fr.get(instr.X))
+		if instr.Op == token.ARROW {
+			fr.env[instr] = recvUnOp(fr, instr, fr.get(instr.X).(chan Value))
+		} else {
+			fr.env[instr] = unop(instr, fr.get(instr.X))
+		}
 
 	case *ssa2.BinOp:
 		fr.env[instr] = binop(instr.Op, instr.X.Type(), fr.get(instr.X), fr.get(instr.Y))
@@ -196,10 +214,20 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		fr.sourcePanic(ToInspect(fr.get(instr.X), nil))
 
 	case *ssa2.Send:
-		fr.get(instr.Chan).(chan Value) <- copyVal(fr.get(instr.X))
+		ch := fr.get(instr.Chan).(chan Value)
+		if sched != nil && sched.isProgramChan(ch) {
+			sched.send(fr, ch, copyVal(fr.get(instr.X)))
+		} else {
+			hostSend(fr, ch, copyVal(fr.get(instr.X)))
+		}
 
 	case *ssa2.Store:
-		*fr.get(instr.Addr).(*Value) = copyVal(fr.get(instr.Val))
+		addr := fr.get(instr.Addr).(*Value)
+		if Recording() { recordWrite(addr) }
+		*addr = copyVal(fr.get(instr.Val))
+		if WriteHook != nil {
+			WriteHook(fr, instr)
+		}
 
 	case *ssa2.If:
 		succ := 1
@@ -225,10 +253,19 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 
 	case *ssa2.Go:
 		fn, args := prepareCall(fr, &instr.Call)
-		go call(fr.i, i.nGoroutines, nil, fn, args)
+		goNum := fr.i.newGoroutine(fr.fn, instr.Pos())
+		fr.startedGoNum = goNum
+		if sched != nil { sched.add(goNum) }
+		TraceHook(fr, &genericInstr, ssa2.GOROUTINE_START)
+		go fr.i.runGoroutine(goNum, fn, args)
+		if sched != nil { sched.yield(fr) }
 
 	case *ssa2.MakeChan:
-		fr.env[instr] = make(chan Value, asInt(fr.get(instr.Size)))
+		if sched != nil {
+			fr.env[instr] = sched.makeChan(asInt(fr.get(instr.Size)))
+		} else {
+			fr.env[instr] = make(chan Value, asInt(fr.get(instr.Size)))
+		}
 
 	case *ssa2.Alloc:
 		var addr *Value
@@ -301,6 +338,7 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		m := fr.get(instr.Map)
 		key := fr.get(instr.Key)
 		v := fr.get(instr.Value)
+		if Recording() { recordMapWrite(m, key) }
 		switch m := m.(type) {
 		case map[Value]Value:
 			m[key] = v
@@ -309,6 +347,9 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		default:
 			panic(fmt.Sprintf("illegal map type: %T", m))
 		}
+		if WriteHook != nil {
+			WriteHook(fr, instr)
+		}
 
 	case *ssa2.TypeAssert:
 		fr.env[instr] = typeAssert(fr.i, instr, fr.get(instr.X).(iface))
@@ -316,10 +357,14 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 	case *ssa2.Trace:
 		fr.startP = instr.Start
 		fr.endP   = instr.End
+		if Recording() { recordStmt(fr, instr) }
 		if (fr.tracing == TRACE_STEP_IN) ||
-			instr.Breakpoint ||
 			(fr.tracing == TRACE_STEP_OVER) && GlobalStmtTracing() {
 			TraceHook(fr, &genericInstr, instr.Event)
+		} else if instr.Breakpoint {
+			// Only a breakpoint brings us here. Say so, so the hook
+			// can decide whether to stop, e.g. by testing a condition.
+			TraceHook(fr, &genericInstr, ssa2.BREAKPOINT)
 		}
 
 	case *ssa2.MakeClosure:
@@ -338,32 +383,47 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 		}
 
 	case *ssa2.Select:
-		var cases []reflect.SelectCase
-		if !instr.Blocking {
-			cases = append(cases, reflect.SelectCase{
-				Dir: reflect.SelectDefault,
-			})
-		}
-		for _, state := range instr.States {
-			var dir reflect.SelectDir
-			if state.Dir == types.RecvOnly {
-				dir = reflect.SelectRecv
-			} else {
-				dir = reflect.SelectSend
+		var chosen int
+		var recv Value
+		var recvOk bool
+		var dcases []chanCase
+		isProgram := false
+		if sched != nil { dcases, isProgram = sched.selectCases(fr, instr) }
+		if isProgram {
+			chosen, recv, recvOk = sched.selectOp(fr, dcases, instr.Blocking,
+				selectWaitReason(len(dcases)))
+		} else {
+			var cases []reflect.SelectCase
+			if !instr.Blocking {
+				cases = append(cases, reflect.SelectCase{
+					Dir: reflect.SelectDefault,
+				})
 			}
-			var send reflect.Value
-			if state.Send != nil {
-				send = reflect.ValueOf(fr.get(state.Send))
+			for _, state := range instr.States {
+				var dir reflect.SelectDir
+				if state.Dir == types.RecvOnly {
+					dir = reflect.SelectRecv
+				} else {
+					dir = reflect.SelectSend
+				}
+				var send reflect.Value
+				if state.Send != nil {
+					send = reflect.ValueOf(fr.get(state.Send))
+				}
+				cases = append(cases, reflect.SelectCase{
+					Dir:  dir,
+					Chan: reflect.ValueOf(fr.get(state.Chan)),
+					Send: send,
+				})
 			}
-			cases = append(cases, reflect.SelectCase{
-				Dir:  dir,
-				Chan: reflect.ValueOf(fr.get(state.Chan)),
-				Send: send,
-			})
-		}
-		chosen, recv, recvOk := reflect.Select(cases)
-		if !instr.Blocking {
-			chosen-- // default case should have index -1.
+			var recvV reflect.Value
+			if instr.Blocking {
+				chosen, recvV, recvOk = hostSelect(fr, cases)
+			} else {
+				chosen, recvV, recvOk = reflect.Select(cases)
+				chosen-- // default case should have index -1.
+			}
+			if recvOk { recv = recvV.Interface().(Value) }
 		}
 		r := tuple{chosen, recvOk}
 		for i, st := range instr.States {
@@ -371,7 +431,7 @@ func visitInstr(fr *Frame, genericInstr ssa2.Instruction) continuation {
 				var v Value
 				if i == chosen && recvOk {
 					// No need to copy since send makes an unaliased copy.
-					v = recv.Interface().(Value)
+					v = recv
 				} else {
 					v = zero(st.Chan.Type().Underlying().(*types.Chan).Elem())
 				}
@@ -498,7 +558,7 @@ func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args [
 		Var2Reg : make(map[string]string),
 		Reg2Var : make(map[string]string),
 	}
-	i.goTops[goNum].Fr = fr
+	i.goTop(goNum).Fr = fr
 
 	fr.env = make(map[ssa2.Value]Value)
 	fr.block = fn.Blocks[0]
@@ -518,7 +578,8 @@ func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args [
 		if GlobalStmtTracing() {
 			fr.tracing = TRACE_STEP_IN
 		}
-	} else if caller.tracing == TRACE_STEP_IN {
+	} else if caller.tracing == TRACE_STEP_IN &&
+		(StepInSkip == nil || !StepInSkip(fn)) {
 		fr.tracing = TRACE_STEP_IN
 	}
 
@@ -527,6 +588,7 @@ func callSSA(i *interpreter, goNum int, caller *Frame, fn *ssa2.Function, args [
 	}
 	// Destroy the locals to avoid accidental use after return.
 	for i := range fn.Locals {
+		if Recording() { recordWrite(&fr.locals[i]) }
 		fr.locals[i] = bad{}
 	}
 	return fr.result
@@ -560,6 +622,50 @@ func runFrame(fr *Frame) {
 		}
 		fr.panicking = true
 		fr.panic = recover()
+		if rp, ok := fr.panic.(restartPanic); ok {
+			// Unwinding to the frame of a checkpoint being
+			// restarted. Frames in between are abandoned without
+			// running their deferred calls.
+			fr.panicking, fr.panic = false, nil
+			if rp.cp.fr != fr {
+				fr.status = StComplete
+				panic(rp)
+			}
+			fr.i.goTop(fr.goNum).Fr = fr
+			fr.restart = rp.cp
+			return
+		}
+		if _, ok := fr.panic.(blockedPanic); ok {
+			// A call made from the debugger would block. Its
+			// frames are abandoned.
+			fr.panicking, fr.panic = false, nil
+			fr.status = StComplete
+			panic(blockedPanic{})
+		}
+		if rp, ok := fr.panic.(returnPanic); ok {
+			// The debugger is making a frame return early. The
+			// frames it called return too. All of them run their
+			// deferred calls.
+			fr.panicking, fr.panic = false, nil
+			fr.runDefers()
+			fr.status = StComplete
+			if rp.fr != fr { panic(rp) }
+			fr.i.goTop(fr.goNum).Fr = fr
+			fr.result = rp.result
+			if addrs := fr.namedResults(); addrs != nil {
+				// As with a return statement, deferred calls may
+				// have changed the named results.
+				fr.result = namedResultValue(addrs)
+			}
+			TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.CALL_RETURN)
+			fr.block = nil
+			return
+		}
+		if _, exiting := fr.panic.(exitPanic); !exiting && !fr.panicReported {
+			// A runtime error in this frame. Stop before the
+			// defers run.
+			fr.reportPanic()
+		}
 		if InstTracing() || GlobalStmtTracing() {
 			fmt.Fprintf(os.Stderr, "Panicking (error type %T): %v.\n", fr.panic, fr.panic)
 			debug.PrintStack()
@@ -572,14 +678,23 @@ func runFrame(fr *Frame) {
 	}()
 
 	fn        := fr.fn
-	fr.startP = fn.Pos()
-	fr.endP   = fn.Pos()
-	if ((fr.tracing == TRACE_STEP_IN) &&
-		(len(fr.block.Instrs) > 0 && GlobalStmtTracing()) ||
-		fn.Breakpoint ) {
-		event := ssa2.CALL_ENTER
-		if fn.Breakpoint { event = ssa2.BREAKPOINT }
-		TraceHook(fr, &fr.block.Instrs[0], event)
+	pc        := 0
+	if cp := fr.restart; cp != nil {
+		// Resuming at a checkpoint: stop where it was taken, then
+		// carry on from there.
+		fr.restart = nil
+		pc = cp.next
+		TraceHook(fr, &fr.block.Instrs[cp.pc], cp.Event)
+	} else {
+		fr.startP = fn.Pos()
+		fr.endP   = fn.Pos()
+		if ((fr.tracing == TRACE_STEP_IN) &&
+			(len(fr.block.Instrs) > 0 && GlobalStmtTracing()) ||
+			fn.Breakpoint ) {
+			event := ssa2.CALL_ENTER
+			if fn.Breakpoint { event = ssa2.BREAKPOINT }
+			TraceHook(fr, &fr.block.Instrs[0], event)
+		}
 	}
 	for {
 		var instr ssa2.Instruction
@@ -588,7 +703,8 @@ func runFrame(fr *Frame) {
 		}
 	block:
 		// rocky: changed to allow for debugger "jump" command
-		for fr.pc = 0; fr.pc < len(fr.block.Instrs); fr.pc++ {
+		for fr.pc = pc; fr.pc < len(fr.block.Instrs); fr.pc++ {
+			parkIfStopped(fr)
 			instr = fr.block.Instrs[fr.pc]
 			if InstTracing() {
 				fmt.Fprint(os.Stderr, fr.pc, "\t")
@@ -631,6 +747,7 @@ func runFrame(fr *Frame) {
 				break block
 			}
 		}
+		pc = 0
 	}
 }
 
@@ -643,7 +760,10 @@ func doRecover(caller *Frame) Value {
 	if caller.i.Mode&DisableRecover == 0 &&
 		caller != nil && !caller.panicking &&
 		caller.caller != nil && caller.caller.panicking {
+		TraceHook(caller, &caller.block.Instrs[caller.pc], ssa2.RECOVER)
 		caller.caller.panicking = false
+		caller.caller.panicReported = false
+		caller.i.goTop(caller.goNum).panicFr = nil
 		p := caller.caller.panic
 		caller.caller.panic = nil
 		switch p := p.(type) {
@@ -732,6 +852,9 @@ func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes type
 		i.TraceMode &= ^(EnableStmtTracing|EnableTracing)
 	}
 	i.goTops = append(i.goTops, &GoreState{Fr: nil, state: 0})
+	sched = nil
+	if mode&Deterministic != 0 { sched = newScheduler(scheduleSeed) }
+	resetWaits()
 
 	initReflect(i)
 
@@ -775,7 +898,14 @@ func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes type
 	exitCode = 2
 	defer func() {
 		if exitCode != 2 || i.Mode&DisableRecover != 0 {
-			TraceHook(i.goTops[0].Fr, nil, ssa2.PROGRAM_TERMINATION)
+			if exitCode == 0 && i.Mode&EnableLeakCheck != 0 {
+				i.findLeaks()
+				if len(i.leaks) > 0 {
+					i.reportLeaks(os.Stderr)
+					exitCode = LeakExitCode
+				}
+			}
+			TraceHook(i.goTop(0).Fr, nil, ssa2.PROGRAM_TERMINATION)
 			return
 		}
 		switch p := recover().(type) {
@@ -791,7 +921,8 @@ func Interpret(mainpkg *ssa2.Package, mode Mode, traceMode TraceMode, sizes type
 		default:
 			fmt.Fprintf(os.Stderr, "panic: unexpected type: %T: %v\n", p, p)
 		}
-		TraceHook(i.goTops[0].Fr, nil, ssa2.PROGRAM_TERMINATION)
+		i.postMortem(0)
+		TraceHook(i.goTop(0).Fr, nil, ssa2.PROGRAM_TERMINATION)
 
 		// TODO(adonovan): dump panicking interpreter goroutine?
 		// buf := make([]byte, 0x10000)
@@ -8,8 +8,12 @@ alike.
 */
 package interp
 import (
+	"errors"
 	"fmt"
+	"go/token"
 	"os"
+	"runtime"
+	"github.com/rocky/go-types"
 	"github.com/rocky/ssa-interp"
 )
 
@@ -49,7 +53,7 @@ func (fr *Frame) sourcePanic(mess string) {
 		runtime۰Gotraceback(fr)
 	case "2", "crash":
 		runtime۰Gotraceback(fr)
-		for _, goTop := range fr.i.goTops {
+		for _, goTop := range fr.i.allGoTops() {
 			otherFr := goTop.Fr
 			if otherFr != fr {
 				runtime۰Gotraceback(otherFr)
@@ -57,7 +61,7 @@ func (fr *Frame) sourcePanic(mess string) {
 		}
 	}
 
-	TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.PANIC)
+	fr.reportPanic()
 	// Don't know if setting fr.status really does anything, but
 	// just to try to be totally Kosher. We do this *after*
 	// running TraceHook because TraceHook treats panic'd frames
@@ -71,6 +75,280 @@ func (fr *Frame) sourcePanic(mess string) {
 	panic(mess)
 }
 
+// reportPanic raises the PANIC event for a panic that starts in
+// frame fr, and remembers fr for post-mortem debugging.
+func (fr *Frame) reportPanic() {
+	fr.i.goTop(fr.goNum).panicFr = fr
+	TraceHook(fr, &fr.block.Instrs[fr.pc], ssa2.PANIC)
+	fr.panicReported = true
+}
+
+// panicString describes panic value p the way the top-level handler
+// in Interpret does.
+func panicString(p interface{}) string {
+	switch p := p.(type) {
+	case targetPanic:
+		return toString(p.v)
+	case runtime.Error:
+		return p.Error()
+	case string:
+		return p
+	}
+	return fmt.Sprintf("%v", p)
+}
+
+// PanicString describes the panic unwinding through frame fr. It is
+// "" if fr isn't panicking.
+func (fr *Frame) PanicString() string {
+	if !fr.panicking { return "" }
+	return panicString(fr.panic)
+}
+
+// postMortem enters the debugger at the frame where the panic that is
+// killing goroutine goNum started, if post-mortem debugging is on.
+// The frames of the goroutine haven't been torn down, so they can
+// still be inspected.
+func (i *interpreter) postMortem(goNum int) {
+	if i.Mode&EnablePostMortem == 0 { return }
+	if fr := i.goTop(goNum).panicFr; fr != nil {
+		TraceHook(fr, nil, ssa2.POSTMORTEM)
+	}
+}
+
+// runGoroutine runs the function of a "go" statement as goroutine
+// goNum. Like the main goroutine, an unrecovered panic ends the
+// program; with post-mortem debugging on we first go into the
+// debugger.
+func (i *interpreter) runGoroutine(goNum int, fn Value, args []Value) {
+	if sched != nil {
+		sched.start(goNum)
+		defer sched.exit(goNum)
+	}
+	defer i.goFinished(goNum)
+	defer i.goExiting(goNum)
+	if i.Mode&EnablePostMortem != 0 {
+		defer func() {
+			p := recover()
+			if p == nil { return }
+			if _, ok := p.(exitPanic); ok { panic(p) }
+			fmt.Fprintln(os.Stderr, "panic:", panicString(p))
+			i.postMortem(goNum)
+			TraceHook(i.goTop(goNum).Fr, nil, ssa2.PROGRAM_TERMINATION)
+			os.Exit(2)
+		}()
+	}
+	call(i, goNum, nil, fn, args)
+}
+
+// goExiting raises the GOROUTINE_EXIT event for goroutine goNum,
+// however its function ended: by returning, by an unrecovered panic,
+// by runtime.Goexit or by the debugger unwinding it.
+func (i *interpreter) goExiting(goNum int) {
+	fr := i.goTop(goNum).Fr
+	if fr == nil { return }
+	for fr.caller != nil { fr = fr.caller }
+	var instr *ssa2.Instruction
+	if fr.block != nil && fr.pc < len(fr.block.Instrs) {
+		instr = &fr.block.Instrs[fr.pc]
+	} else {
+		instr = returnInstr(fr.fn)
+	}
+	if instr == nil { return }
+	TraceHook(fr, instr, ssa2.GOROUTINE_EXIT)
+}
+
+// returnInstr gives a return instruction of fn, or nil if it has none.
+func returnInstr(fn *ssa2.Function) *ssa2.Instruction {
+	for _, b := range fn.Blocks {
+		if n := len(b.Instrs); n > 0 {
+			if _, ok := b.Instrs[n-1].(*ssa2.Return); ok {
+				return &b.Instrs[n-1]
+			}
+		}
+	}
+	return nil
+}
+
+// newGoroutine registers a goroutine about to be started by the "go"
+// statement at pos of function fn, and returns its goroutine number.
+func (i *interpreter) newGoroutine(fn *ssa2.Function, pos token.Pos) int {
+	gocall.Lock()
+	defer gocall.Unlock()
+	waitLock.Lock()
+	i.goTops = append(i.goTops, &GoreState{Fr: nil, state: 0,
+		startFn: fn, startPos: pos})
+	waitLock.Unlock()
+	i.nGoroutines++
+	goStarted()
+	return len(i.goTops) - 1
+}
+
 func (i *interpreter) Program() *ssa2.Program { return i.prog }
 func (i  *interpreter) Globals() map[ssa2.Value]*Value { return i.globals }
-func (i  *interpreter) GoTops() []*GoreState { return i.goTops }
+func (i  *interpreter) GoTops() []*GoreState { return i.allGoTops() }
+
+// RunningFrame gives the innermost frame of goroutine goNum that is
+// still running, or nil if the goroutine has finished.
+func (i *interpreter) RunningFrame(goNum int) *Frame {
+	if goNum < 0 || goNum >= len(i.allGoTops()) { return nil }
+	if chain := i.runningChain(goNum); len(chain) > 0 { return chain[0] }
+	return nil
+}
+
+// CallFunction calls fn, a function or closure of the interpreted
+// program, with args, on behalf of the debugger stopped in frame fr.
+// The call runs in fr's goroutine as if fr had made it, but without
+// stepping. A panic in the call comes back as an error rather than
+// unwinding the program, as does, under the deterministic scheduler,
+// a channel operation or lock that would block while the program is
+// stopped.
+func CallFunction(fr *Frame, fn Value, args []Value) (results []Value, err error) {
+	var sig *types.Signature
+	switch f := fn.(type) {
+	case *ssa2.Function:
+		sig = f.Signature
+	case *closure:
+		sig = f.Fn.Signature
+	default:
+		return nil, fmt.Errorf("can't call %T", fn)
+	}
+	// Put back what the call changes in fr and its goroutine.
+	goTop := fr.i.goTop(fr.goNum)
+	saveTop, savePanicFr := goTop.Fr, goTop.panicFr
+	saveTracing, saveReported := fr.tracing, fr.panicReported
+	fr.tracing = TRACE_STEP_NONE
+	defer func() {
+		goTop.Fr, goTop.panicFr = saveTop, savePanicFr
+		fr.tracing, fr.panicReported = saveTracing, saveReported
+		if x := recover(); x != nil {
+			if _, blocked := x.(blockedPanic); blocked {
+				results, err = nil, errors.New("would block while the program is stopped")
+				return
+			}
+			if _, exiting := x.(exitPanic); exiting || IsUnwind(x) {
+				panic(x)
+			}
+			results, err = nil, fmt.Errorf("panic: %s", panicString(x))
+		}
+	}()
+	result := call(fr.i, fr.goNum, fr, fn, args)
+	switch sig.Results().Len() {
+	case 0:
+		return nil, nil
+	case 1:
+		return []Value{result}, nil
+	}
+	return []Value(result.(tuple)), nil
+}
+
+// InterfaceMethod finds the method that calling meth, a method of an
+// interface type, on interface value v runs: the method of v's
+// dynamic type. It also gives the receiver to pass to the method.
+func InterfaceMethod(fr *Frame, v Value, meth *types.Func) (*ssa2.Function, Value, error) {
+	recv, ok := v.(iface)
+	if !ok || recv.t == nil {
+		return nil, nil, errors.New("method called on nil interface")
+	}
+	fn := lookupMethod(fr.i, recv.t, meth)
+	if fn == nil {
+		return nil, nil, fmt.Errorf("method set for dynamic type %v does not contain %s",
+			recv.t, meth.Name())
+	}
+	return fn, copyVal(recv.v), nil
+}
+
+// returnPanic unwinds the interpreter's Go stack to a frame that the
+// debugger makes return early.
+type returnPanic struct {
+	fr     *Frame
+	result Value
+}
+
+// blockedPanic unwinds a call made from the debugger that would
+// block, back to CallFunction.
+type blockedPanic struct{}
+
+// IsUnwind reports whether x, a value passed to panic, is the
+// debugger unwinding the stack to restart a checkpoint, to return
+// from a frame early or to abandon a call that would block. Anything that recovers panics while
+// interpreting must let these through.
+func IsUnwind(x interface{}) bool {
+	switch x.(type) {
+	case restartPanic, returnPanic, blockedPanic:
+		return true
+	}
+	return false
+}
+
+// ForceReturn makes frame fr return results right away. fr must be
+// running in the goroutine that is stopped in the debugger. As with a
+// return statement, results are first assigned to the named results
+// of fr, if it has them; with no results, the named results keep
+// their values. The deferred calls of fr, and of the frames
+// it has called, run next and may change them; then TraceHook is
+// called with CALL_RETURN for fr. ForceReturn doesn't return unless
+// there is an error.
+func ForceReturn(fr *Frame, results []Value) error {
+	if fr.i.Mode&DisableRecover != 0 {
+		return errors.New("can't return early when recover() is disabled")
+	}
+	if fr.status != StRunning || fr.block == nil || fr.panicking {
+		return errors.New("the frame isn't running")
+	}
+	sig := fr.fn.Signature.Results()
+	addrs := fr.namedResults()
+	switch {
+	case addrs != nil && len(results) == len(addrs):
+		for k, addr := range addrs {
+			Store(fr, addr, results[k])
+		}
+	case len(results) == 0 && sig.Len() > 0 && addrs == nil:
+		// A bare return, but the named results aren't in memory.
+		for k := 0; k < sig.Len(); k++ {
+			results = append(results, zero(sig.At(k).Type()))
+		}
+	}
+	var result Value
+	switch len(results) {
+	case 0:
+	case 1:
+		result = results[0]
+	default:
+		result = tuple(results)
+	}
+	panic(returnPanic{fr, result})
+}
+
+// namedResults gives the addresses of the named results of frame fr,
+// in order. It is nil if the results aren't named, or if they have
+// been lifted into registers, where deferred calls can't see them.
+func (fr *Frame) namedResults() []*Value {
+	res := fr.fn.Signature.Results()
+	if res.Len() == 0 || res.At(0).Name() == "" { return nil }
+	addrs := make([]*Value, res.Len())
+	for _, b := range fr.fn.Blocks {
+		for _, instr := range b.Instrs {
+			alloc, ok := instr.(*ssa2.Alloc)
+			if !ok || !alloc.Pos().IsValid() { continue }
+			for k := range addrs {
+				if alloc.Pos() != res.At(k).Pos() { continue }
+				if addr, ok := fr.env[alloc].(*Value); ok { addrs[k] = addr }
+			}
+		}
+	}
+	for _, addr := range addrs {
+		if addr == nil { return nil }
+	}
+	return addrs
+}
+
+// namedResultValue gives the result of a frame whose named results
+// are at addrs, the way its recover block would load them.
+func namedResultValue(addrs []*Value) Value {
+	if len(addrs) == 1 { return copyVal(*addrs[0]) }
+	res := make([]Value, len(addrs))
+	for k, addr := range addrs {
+		res[k] = copyVal(*addr)
+	}
+	return tuple(res)
+}
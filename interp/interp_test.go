@@ -177,6 +177,11 @@ var gorootSrcTests = []string{
 type successPredicate func(exitcode int, output string) error
 
 func run(t *testing.T, dir, input string, success successPredicate) bool {
+	return runMode(t, dir, input, 0, success)
+}
+
+// runMode is run with interpreter mode mode.
+func runMode(t *testing.T, dir, input string, mode interp.Mode, success successPredicate) bool {
 	fmt.Printf("Input: %s\n", input)
 
 	start := time.Now()
@@ -250,7 +255,7 @@ func run(t *testing.T, dir, input string, success successPredicate) bool {
 	interp.CapturedOutput = &out
 
 	hint = fmt.Sprintf("To trace execution, run:\n%% go build golang.org/x/tools/cmd/ssadump && ./ssadump -build=C -run --interp=T %s\n", input)
-	exitCode := interp.Interpret(mainPkg, 0, 0, &types.StdSizes{8, 8}, inputs[0], []string{})
+	exitCode := interp.Interpret(mainPkg, mode, 0, &types.StdSizes{8, 8}, inputs[0], []string{})
 
 	// The definition of success varies with each file.
 	if err := success(exitCode, out.String()); err != nil {
@@ -299,6 +304,49 @@ func TestTestdataFiles(t *testing.T) {
 	printFailures(failures)
 }
 
+// TestDeterministic runs testdata/sched.go under the deterministic
+// scheduler, checking that runs with the same seed give the same
+// output and leave no goroutines running.
+func TestDeterministic(t *testing.T) {
+	for _, seed := range []int64{1, 2, 42} {
+		var outputs []string
+		record := func(exitcode int, output string) error {
+			outputs = append(outputs, output)
+			return success(exitcode, output)
+		}
+		interp.SetScheduleSeed(seed)
+		mode := interp.Deterministic | interp.EnableLeakCheck
+		if !runMode(t, "testdata"+slash, "sched.go", mode, record) {
+			continue
+		}
+		interp.SetScheduleSeed(seed)
+		if !runMode(t, "testdata"+slash, "sched.go", mode, record) {
+			continue
+		}
+		if outputs[0] != outputs[1] {
+			t.Errorf("seed %d gave different runs:\n%s\nand:\n%s",
+				seed, outputs[0], outputs[1])
+		}
+	}
+}
+
+// TestLeakCheck checks that testdata/leak.go is found to leak a
+// goroutine, with and without the deterministic scheduler, and that
+// this fails the program only with EnableLeakCheck.
+func TestLeakCheck(t *testing.T) {
+	leaked := func(exitcode int, output string) error {
+		if exitcode != interp.LeakExitCode {
+			return fmt.Errorf("exit code was %d, not %d", exitcode,
+				interp.LeakExitCode)
+		}
+		return nil
+	}
+	for _, mode := range []interp.Mode{0, interp.Deterministic} {
+		runMode(t, "testdata"+slash, "leak.go", mode|interp.EnableLeakCheck, leaked)
+		runMode(t, "testdata"+slash, "leak.go", mode, success)
+	}
+}
+
 // TestGorootTest runs the interpreter on $GOROOT/test/*.go.
 func TestGorootTest(t *testing.T) {
 	if testing.Short() {
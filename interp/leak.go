@@ -0,0 +1,96 @@
+// Copyright 2015 Rocky Bernstein.
+
+/*
+This file finds the goroutines the program leaks: those still alive
+when main.main returns. This is only done when EnableLeakCheck is
+set; they are then reported and fail the program.
+
+Goroutines that are running rather than blocked when main.main returns
+may be about to finish, for example just after a sync.WaitGroup.Done
+that let main.main go on. So they get up to leakGrace to finish or
+block before the leaks are taken; under the deterministic scheduler
+they are run in that time.
+*/
+package interp
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"time"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// LeakExitCode is the exit code of a program that leaks goroutines
+// when EnableLeakCheck is set.
+const LeakExitCode = 3
+
+const leakGrace = 100 * time.Millisecond
+
+// liveGoroutines gives the goroutines other than the main one that
+// haven't finished.
+func (i *interpreter) liveGoroutines() []int {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	var live []int
+	for goNum, g := range i.goTops {
+		if goNum != 0 && !g.finished { live = append(live, goNum) }
+	}
+	return live
+}
+
+// allBlocked reports whether each of goroutines goNums is blocked.
+func (i *interpreter) allBlocked(goNums []int) bool {
+	waitLock.Lock()
+	defer waitLock.Unlock()
+	for _, goNum := range goNums {
+		if i.goTops[goNum].waitReason == "" { return false }
+	}
+	return true
+}
+
+// settle gives the goroutines still running up to leakGrace to finish
+// or block.
+func (i *interpreter) settle() {
+	deadline := time.Now().Add(leakGrace)
+	for time.Now().Before(deadline) {
+		if sched != nil {
+			if !sched.runOthers() { return }
+			continue
+		}
+		live := i.liveGoroutines()
+		if len(live) == 0 || i.allBlocked(live) { return }
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// findLeaks records the goroutines leaked by the program, which has
+// just returned from main.main.
+func (i *interpreter) findLeaks() {
+	i.settle()
+	i.leaks = i.liveGoroutines()
+}
+
+// Leaks gives the goroutines that were still alive when main.main
+// returned. It is empty until then, and unless EnableLeakCheck is set.
+func (i *interpreter) Leaks() []int { return i.leaks }
+
+// StartFn gives the function whose "go" statement started the
+// goroutine, or nil for the main goroutine.
+func (g *GoreState) StartFn() *ssa2.Function { return g.startFn }
+
+// StartPos gives the position of the "go" statement that started the
+// goroutine.
+func (g *GoreState) StartPos() token.Pos { return g.startPos }
+
+// reportLeaks writes the goroutines leaked to w, with what each is
+// blocked in, its stack and where it was started.
+func (i *interpreter) reportLeaks(w io.Writer) {
+	fmt.Fprintf(w, "%d goroutine(s) still running when main.main returned:\n",
+		len(i.leaks))
+	for _, goNum := range i.leaks {
+		fmt.Fprintln(w)
+		i.writeGoroutine(w, goNum)
+	}
+}
@@ -967,7 +967,12 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 		return copy(args[0].([]Value), src.([]Value))
 
 	case "close": // close(chan T)
-		close(args[0].(chan Value))
+		ch := args[0].(chan Value)
+		if sched != nil && sched.isProgramChan(ch) {
+			sched.closeChan(ch)
+		} else {
+			close(ch)
+		}
 		return nil
 
 	case "delete": // delete(map[K]Value, K)
@@ -1011,6 +1016,7 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 		case *hashmap:
 			return x.len()
 		case chan Value:
+			if sched != nil && sched.isProgramChan(x) { return sched.chanLen(x) }
 			return len(x)
 		default:
 			panic(fmt.Sprintf("len: illegal operand: %T", x))
@@ -1025,6 +1031,7 @@ func callBuiltin(caller *Frame, fn *ssa2.Builtin, args []Value) Value {
 		case []Value:
 			return cap(x)
 		case chan Value:
+			if sched != nil && sched.isProgramChan(x) { return sched.chanCap(x) }
 			return cap(x)
 		default:
 			panic(fmt.Sprintf("cap: illegal operand: %T", x))
@@ -0,0 +1,300 @@
+// Copyright 2015 Rocky Bernstein.
+
+/*
+This file records an execution history so that a debugger can go
+backwards. At each statement boundary (a Trace instruction) we save
+where the frame and its callers are, along with the frame's
+registers. Between statement boundaries, each write to memory saves
+the value it overwrites. Going back undoes writes newest first; going
+forward again redoes them.
+
+Writes made by built-ins like copy() and append(), channel
+operations, and anything outside the interpreter are not undone.
+*/
+package interp
+
+import (
+	"go/token"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rocky/ssa-interp"
+)
+
+// A memWrite is what is needed to undo and redo a write to memory: a
+// store through a pointer or an update of a map entry.
+type memWrite struct {
+	addr   *Value // for a store; nil for a map update
+	m      Value  // map for a map update
+	key    Value
+	old    Value
+	oldOK  bool   // for a map: the entry existed before the write
+	new    Value  // filled in when the write is undone
+	newOK  bool
+}
+
+func (w *memWrite) get() (Value, bool) {
+	if w.addr != nil { return *w.addr, true }
+	return MapLookup(w.m, w.key)
+}
+
+func (w *memWrite) set(v Value, ok bool) {
+	if w.addr != nil {
+		*w.addr = v
+		return
+	}
+	switch m := w.m.(type) {
+	case map[Value]Value:
+		if ok {
+			m[w.key] = v
+		} else {
+			delete(m, w.key)
+		}
+	case *hashmap:
+		if ok {
+			m.insert(w.key.(hashable), v)
+		} else {
+			m.delete(w.key.(hashable))
+		}
+	}
+}
+
+func (w *memWrite) undo() {
+	w.new, w.newOK = w.get()
+	w.set(w.old, w.oldOK)
+}
+
+func (w *memWrite) redo() {
+	w.set(w.new, w.newOK)
+}
+
+// frameState is the part of a frame that changes as it runs.
+type frameState struct {
+	fr           *Frame
+	block        *ssa2.BasicBlock
+	pc           int
+	startP, endP token.Pos
+	status       RunStatusType
+	env          map[ssa2.Value]Value // saved only for the innermost frame
+}
+
+func saveFrameState(fr *Frame) frameState {
+	return frameState{
+		fr:     fr,
+		block:  fr.block,
+		pc:     fr.pc,
+		startP: fr.startP,
+		endP:   fr.endP,
+		status: fr.status,
+		env:    fr.env,
+	}
+}
+
+func (s *frameState) restore() {
+	fr := s.fr
+	fr.block, fr.pc = s.block, s.pc
+	fr.startP, fr.endP = s.startP, s.endP
+	fr.status = s.status
+	if s.env != nil { fr.env = s.env }
+}
+
+// A HistoryEntry is a statement boundary that was run, along with what
+// it takes to get back there.
+type HistoryEntry struct {
+	Trace  *ssa2.Trace
+	First  bool         // set if this is the first entry for its frame
+	frames []frameState // the entry's frame, then its callers
+	writes []memWrite   // writes after this entry, up to the next one
+	size   int          // estimated memory used
+}
+
+// Frame is the frame that ran the statement of history entry e.
+func (e *HistoryEntry) Frame() *Frame { return e.frames[0].fr }
+
+// InStack reports whether fr was one of the callers of the frame of
+// entry e, when e was recorded.
+func (e *HistoryEntry) InStack(fr *Frame) bool {
+	for _, s := range e.frames[1:] {
+		if s.fr == fr { return true }
+	}
+	return false
+}
+
+// IsAt reports whether e records frame fr as it is now, with nothing
+// written since.
+func (e *HistoryEntry) IsAt(fr *Frame) bool {
+	s := e.frames[0]
+	return s.fr == fr && s.block == fr.block && s.pc == fr.pc &&
+		len(e.writes) == 0
+}
+
+// Rough sizes, in bytes, for keeping within the memory budget.
+const (
+	entrySize = 64
+	frameStateSize = 64
+	envEntrySize = 32
+	writeSize = 96
+)
+
+// history is the recorded execution history.
+type history struct {
+	sync.Mutex
+	on      int32 // 1 while recording; read without the lock on each instruction
+	entries []*HistoryEntry
+	size    int // estimated memory used by entries
+	budget  int // most memory entries may use
+	cursor  int // entry we have gone back to; len(entries) if none
+	live    map[*Frame]frameState // frames as they were before going back
+}
+
+var hist = history{budget: 16 * 1024 * 1024}
+
+// Recording reports whether execution history is being recorded.
+func Recording() bool { return atomic.LoadInt32(&hist.on) != 0 }
+
+// StartRecording starts recording execution history.
+func StartRecording() {
+	hist.Lock()
+	defer hist.Unlock()
+	atomic.StoreInt32(&hist.on, 1)
+}
+
+// StopRecording stops recording and throws away the history. If we
+// have gone back in the history, we first return to the present.
+func StopRecording() {
+	HistoryGoto(HistoryLen())
+	hist.Lock()
+	defer hist.Unlock()
+	atomic.StoreInt32(&hist.on, 0)
+	hist.entries = nil
+	hist.size, hist.cursor = 0, 0
+}
+
+// HistoryBudget is the most memory, in bytes, that the history may use.
+func HistoryBudget() int { return hist.budget }
+
+// HistorySize is an estimate of the memory, in bytes, used by the history.
+func HistorySize() int { return hist.size }
+
+// SetHistoryBudget sets the most memory, in bytes, that the history
+// may use. When the budget is exceeded, the oldest entries are
+// dropped.
+func SetHistoryBudget(budget int) {
+	hist.Lock()
+	defer hist.Unlock()
+	hist.budget = budget
+	hist.trim()
+}
+
+// HistoryLen is the number of entries in the history.
+func HistoryLen() int { return len(hist.entries) }
+
+// HistoryEntryAt gives entry n of the history, oldest first.
+func HistoryEntryAt(n int) *HistoryEntry { return hist.entries[n] }
+
+// HistoryCursor is the entry we have gone back to, or HistoryLen() if
+// we are at the present.
+func HistoryCursor() int { return hist.cursor }
+
+// trim drops the oldest entries until we are within budget. We
+// always keep the latest entry since writes are being added to it.
+// Nothing is dropped while we have gone back in the history: the
+// entries hold the writes needed to get back to the present.
+func (h *history) trim() {
+	if h.cursor != len(h.entries) { return }
+	drop := 0
+	for h.size > h.budget && drop < len(h.entries)-1 {
+		h.size -= h.entries[drop].size
+		drop++
+	}
+	if drop > 0 {
+		h.entries = h.entries[drop:]
+		h.cursor -= drop
+		if h.cursor < 0 { h.cursor = 0 }
+	}
+}
+
+// recordStmt adds an entry for statement boundary instr of frame fr.
+func recordStmt(fr *Frame, instr *ssa2.Trace) {
+	hist.Lock()
+	defer hist.Unlock()
+	if hist.cursor != len(hist.entries) { return } // going over history
+	env := make(map[ssa2.Value]Value, len(fr.env))
+	for k, v := range fr.env {
+		env[k] = v
+	}
+	e := &HistoryEntry{Trace: instr, First: !fr.recorded}
+	fr.recorded = true
+	for f := fr; f != nil; f = f.caller {
+		s := saveFrameState(f)
+		if f != fr { s.env = nil }
+		e.frames = append(e.frames, s)
+	}
+	e.frames[0].env = env
+	e.size = entrySize + len(e.frames)*frameStateSize + len(env)*envEntrySize
+	hist.entries = append(hist.entries, e)
+	hist.size += e.size
+	hist.cursor = len(hist.entries)
+	hist.trim()
+}
+
+// addWrite adds w to the writes of the latest history entry.
+func addWrite(w memWrite) {
+	hist.Lock()
+	defer hist.Unlock()
+	n := len(hist.entries)
+	if n == 0 || hist.cursor != n { return }
+	e := hist.entries[n-1]
+	e.writes = append(e.writes, w)
+	e.size += writeSize
+	hist.size += writeSize
+	hist.trim()
+}
+
+// recordWrite saves the value at addr before it is overwritten.
+func recordWrite(addr *Value) {
+	addWrite(memWrite{addr: addr, old: *addr, oldOK: true})
+}
+
+// recordMapWrite saves the entry for key in map m before it is
+// overwritten.
+func recordMapWrite(m Value, key Value) {
+	old, ok := MapLookup(m, key)
+	addWrite(memWrite{m: m, key: key, old: old, oldOK: ok})
+}
+
+// HistoryGoto makes the state of the program what it was at history
+// entry n, undoing or redoing writes as needed. An n of HistoryLen()
+// returns to the present.
+func HistoryGoto(n int) {
+	hist.Lock()
+	defer hist.Unlock()
+	if n < 0 || n > len(hist.entries) { return }
+	for hist.cursor > n {
+		hist.cursor--
+		writes := hist.entries[hist.cursor].writes
+		for i := len(writes) - 1; i >= 0; i-- {
+			writes[i].undo()
+		}
+	}
+	for hist.cursor < n {
+		for i := range hist.entries[hist.cursor].writes {
+			hist.entries[hist.cursor].writes[i].redo()
+		}
+		hist.cursor++
+	}
+	if n == len(hist.entries) {
+		for _, s := range hist.live {
+			s.restore()
+		}
+		hist.live = nil
+		return
+	}
+	if hist.live == nil { hist.live = make(map[*Frame]frameState) }
+	for _, s := range hist.entries[n].frames {
+		if _, ok := hist.live[s.fr]; !ok {
+			hist.live[s.fr] = saveFrameState(s.fr)
+		}
+		s.restore()
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright 2015 Rocky Bernstein.
+
+/*
+This file has the deterministic scheduler, used when the interpreter
+mode has Deterministic set.
+
+Interpreted goroutines still run on goroutines of the host, but only
+one of them runs at a time. At each scheduling point -- a channel
+operation or select, a "go" statement, runtime.Gosched, a semaphore
+operation of package sync and the end of a goroutine -- the scheduler
+picks the goroutine to run next from those that can run, using a
+pseudo-random number generator started from a seed. Channels made by
+the program are implemented here rather than by channels of the host,
+so that where a value goes depends only on those choices. Running the
+same program on the same input with the same seed therefore gives the
+same run, and a schedule-dependent bug can be replayed from its seed.
+
+Channels that don't come from the program's "make", for example those
+made through reflection or by external functions, are still channels
+of the host. Operations on them hold up every goroutine until they
+finish.
+*/
+package interp
+
+import (
+	"math/rand"
+	"runtime"
+
+	"github.com/rocky/ssa-interp"
+	"github.com/rocky/go-types"
+)
+
+type goStatus int
+
+const (
+	goRunnable goStatus = iota
+	goBlocked
+	goDone
+)
+
+// A goro is the scheduler's view of a goroutine.
+type goro struct {
+	num    int
+	status goStatus
+	wake   chan struct{} // gets a value when the goroutine is to run
+	// ready, for a goroutine blocked on a semaphore, reports whether
+	// it can go on. A goroutine blocked on channels has none: the
+	// goroutine that completes its operation makes it runnable.
+	ready func() bool
+}
+
+// A dchan is a channel of the program.
+type dchan struct {
+	size   int
+	buf    []Value
+	closed bool
+	recvq  []*sudog // goroutines blocked receiving
+	sendq  []*sudog // goroutines blocked sending
+}
+
+// A sudog is one case of a blocked channel operation or select.
+type sudog struct {
+	op    *chanWait
+	index int   // of the case in the select
+	val   Value // value to send
+}
+
+// A chanWait is a channel operation or select a goroutine is blocked
+// in. It is over when fired is set.
+type chanWait struct {
+	g      *goro
+	fired  bool
+	chosen int   // case that fired
+	val    Value // value received
+	ok     bool  // false if the receive was because of a close
+	closed bool  // a send was stopped by a close
+}
+
+// A chanCase is a case of a select; a plain send or receive is a
+// select of one case.
+type chanCase struct {
+	ch   chan Value
+	send bool
+	val  Value
+}
+
+type scheduler struct {
+	rng     *rand.Rand
+	goros   []*goro // indexed by goroutine number
+	current *goro
+	chans   map[chan Value]*dchan
+	// deadlocked is set when a goroutine exits leaving the others
+	// deadlocked; the one woken reports it.
+	deadlocked bool
+}
+
+// sched is nil unless the Deterministic mode is on.
+var sched *scheduler
+
+var scheduleSeed int64 = 1
+
+// SetScheduleSeed sets the seed that the choices of the deterministic
+// scheduler come from.
+func SetScheduleSeed(seed int64) {
+	scheduleSeed = seed
+}
+
+func newScheduler(seed int64) *scheduler {
+	s := &scheduler{
+		rng:   rand.New(rand.NewSource(seed)),
+		chans: make(map[chan Value]*dchan),
+	}
+	s.current = s.add(0)
+	return s
+}
+
+// add registers goroutine goNum, which can run once it is picked.
+func (s *scheduler) add(goNum int) *goro {
+	for len(s.goros) <= goNum {
+		s.goros = append(s.goros, nil)
+	}
+	g := &goro{num: goNum, wake: make(chan struct{}, 1)}
+	s.goros[goNum] = g
+	return g
+}
+
+// runnable gives the goroutines that can run, in goroutine order.
+func (s *scheduler) runnable() []*goro {
+	var gs []*goro
+	for _, g := range s.goros {
+		if g == nil { continue }
+		if g.status == goBlocked && g.ready != nil && g.ready() {
+			g.status = goRunnable
+			g.ready = nil
+		}
+		if g.status == goRunnable { gs = append(gs, g) }
+	}
+	return gs
+}
+
+// pick chooses the goroutine to run next, or returns nil if none can.
+func (s *scheduler) pick() *goro {
+	gs := s.runnable()
+	switch len(gs) {
+	case 0:
+		return nil
+	case 1:
+		return gs[0]
+	}
+	return gs[s.rng.Intn(len(gs))]
+}
+
+// switchTo runs goroutine next, and has g wait until it is picked
+// again.
+func (s *scheduler) switchTo(g *goro, next *goro) {
+	if next == g { return }
+	s.current = next
+	next.wake <- struct{}{}
+	<-g.wake
+}
+
+// yield is a scheduling point of the goroutine of frame fr, which can
+// carry on running. While the debugger has the program stopped, only
+// that goroutine runs.
+func (s *scheduler) yield(fr *Frame) {
+	if WorldStopped() { return }
+	g := s.goros[fr.goNum]
+	s.switchTo(g, s.pick())
+}
+
+// checkCanBlock unwinds a call from the debugger that is about to
+// block: while the program is stopped, no other goroutine can run to
+// unblock it. It is called before anything records the wait.
+func checkCanBlock() {
+	if WorldStopped() { panic(blockedPanic{}) }
+}
+
+// block has the goroutine of frame fr wait, in the operation
+// described by reason, until it can run again.
+func (s *scheduler) block(fr *Frame, reason string) {
+	g := s.goros[fr.goNum]
+	checkCanBlock()
+	g.status = goBlocked
+	waitStart(fr, reason)
+	defer waitEnd(fr)
+	next := s.pick()
+	if next == nil { fr.i.deadlock(fr) }
+	s.switchTo(g, next)
+	if s.deadlocked { fr.i.deadlock(fr) }
+}
+
+// start is called on the host goroutine of goroutine goNum before it
+// runs anything, and waits until the goroutine is picked.
+func (s *scheduler) start(goNum int) {
+	<-s.goros[goNum].wake
+}
+
+// exit takes goroutine goNum out of the schedule and runs another.
+func (s *scheduler) exit(goNum int) {
+	s.goros[goNum].status = goDone
+	next := s.pick()
+	if next == nil {
+		s.handDeadlock()
+		return
+	}
+	s.current = next
+	next.wake <- struct{}{}
+}
+
+// handDeadlock hands the deadlock left by an exiting goroutine to the
+// first blocked goroutine, which is woken to report it in its own
+// frame.
+func (s *scheduler) handDeadlock() {
+	for _, g := range s.goros {
+		if g != nil && g.status == goBlocked {
+			s.deadlocked = true
+			s.current = g
+			g.wake <- struct{}{}
+			return
+		}
+	}
+	i.deadlock(nil)
+}
+
+// runOthers lets a goroutine other than the main one, which must be
+// running, take a turn. It reports false if none can run.
+func (s *scheduler) runOthers() bool {
+	g := s.goros[0]
+	var others []*goro
+	for _, o := range s.runnable() {
+		if o != g { others = append(others, o) }
+	}
+	if len(others) == 0 { return false }
+	s.switchTo(g, others[s.rng.Intn(len(others))])
+	return true
+}
+
+// semacquire waits until *addr is positive and decrements it.
+func (s *scheduler) semacquire(fr *Frame, addr *Value) {
+	ready := func() bool { return (*addr).(uint32) > 0 }
+	if !ready() {
+		checkCanBlock()
+		s.goros[fr.goNum].ready = ready
+		s.block(fr, "semacquire")
+	}
+	*addr = (*addr).(uint32) - 1
+	s.yield(fr)
+}
+
+// semrelease increments *addr, which may let a goroutine in
+// semacquire go on.
+func (s *scheduler) semrelease(fr *Frame, addr *Value) {
+	*addr = (*addr).(uint32) + 1
+	s.yield(fr)
+}
+
+// makeChan makes a channel with a buffer of size values.
+func (s *scheduler) makeChan(size int) chan Value {
+	// The host channel is only the identity of the channel.
+	ch := make(chan Value)
+	s.chans[ch] = &dchan{size: size}
+	return ch
+}
+
+// isProgramChan reports whether ch is a channel made by the program
+// and so is implemented here.
+func (s *scheduler) isProgramChan(ch chan Value) bool {
+	_, ok := s.chans[ch]
+	return ok || ch == nil
+}
+
+// canDo reports whether case c of a select can go ahead now.
+func (s *scheduler) canDo(c *chanCase) bool {
+	d := s.chans[c.ch]
+	if d == nil { return false } // nil channel
+	purge(&d.recvq)
+	purge(&d.sendq)
+	if c.send {
+		return d.closed || len(d.recvq) > 0 || len(d.buf) < d.size
+	}
+	return d.closed || len(d.buf) > 0 || len(d.sendq) > 0
+}
+
+// wakeup ends the wait of sudog sg.
+func wakeup(sg *sudog, val Value, ok bool) {
+	w := sg.op
+	w.fired, w.chosen, w.val, w.ok = true, sg.index, val, ok
+	w.g.status = goRunnable
+}
+
+// purge drops the sudogs at the front of q whose operation has
+// already been done through another case of its select.
+func purge(q *[]*sudog) {
+	for len(*q) > 0 && (*q)[0].op.fired {
+		*q = (*q)[1:]
+	}
+}
+
+// dequeue takes the first sudog from q still waiting, if any.
+func dequeue(q *[]*sudog) *sudog {
+	purge(q)
+	if len(*q) == 0 { return nil }
+	sg := (*q)[0]
+	*q = (*q)[1:]
+	return sg
+}
+
+// do carries out case c, which canDo says can go ahead.
+func (s *scheduler) do(c *chanCase) (Value, bool) {
+	d := s.chans[c.ch]
+	if c.send {
+		if d.closed { panic(errSendOnClosed) }
+		if sg := dequeue(&d.recvq); sg != nil {
+			wakeup(sg, c.val, true)
+			return nil, false
+		}
+		d.buf = append(d.buf, c.val)
+		return nil, false
+	}
+	if len(d.buf) > 0 {
+		v := d.buf[0]
+		d.buf = d.buf[1:]
+		if sg := dequeue(&d.sendq); sg != nil {
+			d.buf = append(d.buf, sg.val)
+			wakeup(sg, nil, false)
+		}
+		return v, true
+	}
+	if sg := dequeue(&d.sendq); sg != nil {
+		wakeup(sg, nil, false)
+		return sg.val, true
+	}
+	return nil, false // closed
+}
+
+// selectOp carries out one of cases for frame fr, picking among those
+// that can go ahead. If none can and block is false it returns -1;
+// otherwise it waits for one, blocked in the operation described by
+// reason. For a receive, ok is false if the channel is closed, and
+// the value is then nil.
+func (s *scheduler) selectOp(fr *Frame, cases []chanCase, block bool,
+	reason string) (chosen int, val Value, ok bool) {
+	s.yield(fr)
+	var ready []int
+	for i := range cases {
+		if s.canDo(&cases[i]) { ready = append(ready, i) }
+	}
+	if len(ready) > 0 {
+		chosen = ready[0]
+		if len(ready) > 1 { chosen = ready[s.rng.Intn(len(ready))] }
+		val, ok = s.do(&cases[chosen])
+		return chosen, val, ok
+	}
+	if !block { return -1, nil, false }
+
+	checkCanBlock()
+	w := &chanWait{g: s.goros[fr.goNum]}
+	for i := range cases {
+		d := s.chans[cases[i].ch]
+		if d == nil { continue }
+		sg := &sudog{op: w, index: i, val: cases[i].val}
+		if cases[i].send {
+			d.sendq = append(d.sendq, sg)
+		} else {
+			d.recvq = append(d.recvq, sg)
+		}
+	}
+	s.block(fr, reason)
+	if w.closed { panic(errSendOnClosed) }
+	return w.chosen, w.val, w.ok
+}
+
+// send sends val on ch for frame fr.
+func (s *scheduler) send(fr *Frame, ch chan Value, val Value) {
+	s.selectOp(fr, []chanCase{{ch: ch, send: true, val: val}}, true,
+		chanWaitReason(ch, true))
+}
+
+// recv receives from ch for frame fr.
+func (s *scheduler) recv(fr *Frame, ch chan Value) (Value, bool) {
+	_, val, ok := s.selectOp(fr, []chanCase{{ch: ch}}, true,
+		chanWaitReason(ch, false))
+	return val, ok
+}
+
+// hostPanic gives the run-time error that f panics with.
+func hostPanic(f func()) (err runtime.Error) {
+	defer func() { err = recover().(runtime.Error) }()
+	f()
+	return nil
+}
+
+// The run-time errors of channel operations, the same values that the
+// operations on channels of the host give, so that the program sees
+// the same thing in recover() whichever kind of channel it uses.
+var (
+	errSendOnClosed = hostPanic(func() {
+		ch := make(chan Value, 1)
+		close(ch)
+		ch <- nil
+	})
+	errCloseNil = hostPanic(func() {
+		var ch chan Value
+		close(ch)
+	})
+	errCloseClosed = hostPanic(func() {
+		ch := make(chan Value)
+		close(ch)
+		close(ch)
+	})
+)
+
+// closeChan closes ch. Blocked receivers get the zero value and
+// blocked senders panic.
+func (s *scheduler) closeChan(ch chan Value) {
+	d := s.chans[ch]
+	if d == nil { panic(errCloseNil) }
+	if d.closed { panic(errCloseClosed) }
+	d.closed = true
+	for sg := dequeue(&d.recvq); sg != nil; sg = dequeue(&d.recvq) {
+		wakeup(sg, nil, false)
+	}
+	for sg := dequeue(&d.sendq); sg != nil; sg = dequeue(&d.sendq) {
+		wakeup(sg, nil, false)
+		sg.op.closed = true
+	}
+}
+
+// chanLen and chanCap give the number of values buffered in, and the
+// buffer size of, channel ch.
+func (s *scheduler) chanLen(ch chan Value) int {
+	if d := s.chans[ch]; d != nil { return len(d.buf) }
+	return 0
+}
+
+func (s *scheduler) chanCap(ch chan Value) int {
+	if d := s.chans[ch]; d != nil { return d.size }
+	return 0
+}
+
+// recvUnOp carries out receive instr from channel ch for frame fr.
+// Unlike unop, it handles channels of the deterministic scheduler and
+// notes when the goroutine is blocked.
+func recvUnOp(fr *Frame, instr *ssa2.UnOp, ch chan Value) Value {
+	var v Value
+	var ok bool
+	if sched != nil && sched.isProgramChan(ch) {
+		v, ok = sched.recv(fr, ch)
+	} else {
+		v, ok = hostRecv(fr, ch)
+	}
+	if !ok {
+		v = zero(instr.X.Type().Underlying().(*types.Chan).Elem())
+	}
+	if instr.CommaOk {
+		v = tuple{v, ok}
+	}
+	return v
+}
+
+// selectCases gives the cases of select instr in frame fr, if all its
+// channels are channels of the program.
+func (s *scheduler) selectCases(fr *Frame, instr *ssa2.Select) ([]chanCase, bool) {
+	var cases []chanCase
+	for _, state := range instr.States {
+		ch := fr.get(state.Chan).(chan Value)
+		if !s.isProgramChan(ch) { return nil, false }
+		c := chanCase{ch: ch, send: state.Dir != types.RecvOnly}
+		if state.Send != nil { c.val = fr.get(state.Send) }
+		cases = append(cases, c)
+	}
+	return cases, true
+}
@@ -0,0 +1,16 @@
+package main
+
+// A program that leaks a goroutine: it is still waiting on a channel
+// nobody sends on when main returns.
+
+func main() {
+	c := make(chan int)
+	done := make(chan bool)
+	go func() {
+		<-c
+	}()
+	go func() {
+		done <- true
+	}()
+	<-done
+}
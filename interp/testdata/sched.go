@@ -0,0 +1,105 @@
+package main
+
+// Tests of channels, select and package sync, run under the
+// deterministic scheduler. The order events are printed in depends on
+// the schedule, so it is the same from one run to the next only if
+// the scheduler is deterministic.
+
+import (
+	"fmt"
+	"sync"
+)
+
+func unbuffered() {
+	c := make(chan int)
+	done := make(chan bool)
+	go func() {
+		for v := range c {
+			fmt.Println("got", v)
+		}
+		done <- true
+	}()
+	for i := 0; i < 3; i++ {
+		c <- i
+		fmt.Println("sent", i)
+	}
+	close(c)
+	<-done
+}
+
+func buffered() {
+	c := make(chan string, 2)
+	c <- "a"
+	c <- "b"
+	if len(c) != 2 || cap(c) != 2 {
+		panic(fmt.Sprint("BUG: len/cap: ", len(c), cap(c)))
+	}
+	if v := <-c; v != "a" {
+		panic("BUG: buffered order: " + v)
+	}
+	close(c)
+	if v, ok := <-c; v != "b" || !ok {
+		panic("BUG: value after close")
+	}
+	if v, ok := <-c; v != "" || ok {
+		panic("BUG: receive from closed channel")
+	}
+}
+
+func selects() {
+	a, b := make(chan int), make(chan int)
+	quit := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func(c chan int, n int) {
+			for j := 0; j < 3; j++ {
+				c <- n*10 + j
+			}
+		}([]chan int{a, b}[i], i)
+	}
+	go func() {
+		for k := 0; k < 6; k++ {
+			select {
+			case v := <-a:
+				fmt.Println("a", v)
+			case v := <-b:
+				fmt.Println("b", v)
+			}
+		}
+		quit <- true
+	}()
+	select {
+	case <-quit:
+	}
+	select {
+	case v := <-a:
+		panic(fmt.Sprint("BUG: nothing should be sent: ", v))
+	default:
+	}
+}
+
+func syncs() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	total := 0
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			mu.Lock()
+			total += n
+			fmt.Println("add", n)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	if total != 10 {
+		panic(fmt.Sprint("BUG: total is ", total))
+	}
+}
+
+func main() {
+	unbuffered()
+	buffered()
+	selects()
+	syncs()
+}
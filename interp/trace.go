@@ -2,6 +2,7 @@ package interp
 
 import (
 	"fmt"
+	"go/token"
 	"github.com/rocky/ssa-interp"
 	"sync"
 )
@@ -20,8 +21,14 @@ const (
 var gocall sync.Mutex
 
 type GoreState struct {
-	Fr     *Frame
-	state  int  // running, finished, etc. Fill this in later
+	Fr      *Frame
+	state   int  // running, finished, etc. Fill this in later
+	panicFr *Frame // frame where an unrecovered panic started
+	waitReason string // operation blocked in; see deadlock.go
+	finished bool     // set when the goroutine has returned
+	parked   bool     // set while held for StopTheWorld; see allstop.go
+	startFn  *ssa2.Function // function with the "go" statement
+	startPos token.Pos      // position of the "go" statement
 }
 
 // TraceMode is a bitmask of options influencing the tracing.
@@ -51,6 +58,33 @@ type TraceHookFunc func(*Frame, *ssa2.Instruction, ssa2.TraceEvent)
 // FIXME: turn into a map of TraceHookFuncs
 var TraceHook TraceHookFunc
 
+// WriteHookFunc is called after instruction instr of frame fr has
+// written to memory. instr is a *ssa2.Store or a *ssa2.MapUpdate, or
+// nil for a write the debugger made through Store or MapUpdate.
+type WriteHookFunc func(fr *Frame, instr ssa2.Instruction)
+
+// WriteHook is nil unless the debugger wants to hear about writes,
+// for example because it is watching some variable.
+var WriteHook WriteHookFunc
+
+func SetWriteHook(hook WriteHookFunc) {
+	WriteHook = hook
+}
+
+// StepInSkipFunc decides whether stepping into a call of fn should
+// step over it instead.
+type StepInSkipFunc func(fn *ssa2.Function) bool
+
+// StepInSkip is nil unless the debugger wants some functions to be
+// stepped over when stepping in. A skipped function runs without
+// tracing, as it would under "next", though breakpoints in it or in
+// what it calls still stop.
+var StepInSkip StepInSkipFunc
+
+func SetStepInSkip(skip StepInSkipFunc) {
+	StepInSkip = skip
+}
+
 // This gets called for special trace events if tracing is on
 // FIXME: Move elsewhere
 func DefaultTraceHook(fr *Frame, instr *ssa2.Instruction, event ssa2.TraceEvent) {
@@ -20,7 +20,7 @@ type Array []Value
 //   * strings are quoted
 //   * separators lists maps are ", " (rather than " ")
 //   * nil is "nil" rather than "<nil>"
-func toInspect(w io.Writer, v Value, name *ssa2.Value) {
+func toInspect(w io.Writer, v Value, valType types.Type) {
 	switch v := v.(type) {
 
 	case nil:
@@ -39,9 +39,9 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 		for k, e := range v {
 			io.WriteString(w, sep)
 			sep = ", "
-			toInspect(w, k, name)
+			toInspect(w, k, valType)
 			io.WriteString(w, ":")
-			toInspect(w, e, name)
+			toInspect(w, e, valType)
 		}
 		io.WriteString(w, "]")
 
@@ -52,9 +52,9 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			for e != nil {
 				io.WriteString(w, sep)
 				sep = ", "
-				toInspect(w, e.key, name)
+				toInspect(w, e.key, valType)
 				io.WriteString(w, ":")
-				toInspect(w, e.Value, name)
+				toInspect(w, e.Value, valType)
 				e = e.next
 			}
 		}
@@ -71,15 +71,15 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 		}
 
 	case iface:
-		toInspect(w, v.v, name)
+		toInspect(w, v.v, valType)
 
 	case Structure:
 		io.WriteString(w, "{")
 		var ok bool = false
 		var typ types.Type
 		var t *types.Struct
-		if name != nil {
-			typ = deref((*name).Type()).Underlying()
+		if valType != nil {
+			typ = deref(valType).Underlying()
 			t, ok = typ.(*types.Struct)
 		}
 		// The vNum, tNum values below are to
@@ -101,7 +101,7 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			} else {
 				fmt.Fprintf(w, "?? ")
 			}
-			toInspect(w, e, name)
+			toInspect(w, e, valType)
 			io.WriteString(w, ",")
 		}
 		io.WriteString(w, "}")
@@ -112,7 +112,7 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			if i > 0 {
 				io.WriteString(w, ", ")
 			}
-			toInspect(w, e, name)
+			toInspect(w, e, valType)
 		}
 		io.WriteString(w, "}")
 
@@ -122,7 +122,7 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			if i > 0 {
 				io.WriteString(w, ", ")
 			}
-			toInspect(w, e, name)
+			toInspect(w, e, valType)
 		}
 		io.WriteString(w, "}")
 
@@ -139,7 +139,7 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 			if i > 0 {
 				io.WriteString(w, ", ")
 			}
-			toInspect(w, e, name)
+			toInspect(w, e, valType)
 		}
 		io.WriteString(w, ")")
 
@@ -151,8 +151,18 @@ func toInspect(w io.Writer, v Value, name *ssa2.Value) {
 // Similar to ToString but using toInspect
 // Note: we can't use a method because the receiver is an interface type.
 func ToInspect(v Value, name *ssa2.Value) string {
+	var valType types.Type
+	if name != nil {
+		valType = (*name).Type()
+	}
+	return ToInspectType(v, valType)
+}
+
+// ToInspectType is like ToInspect but takes the type used to name
+// structure fields directly, for values that don't have an SSA name.
+func ToInspectType(v Value, valType types.Type) string {
 	var b bytes.Buffer
-	toInspect(&b, v, name)
+	toInspect(&b, v, valType)
 	return b.String()
 }
 
@@ -241,6 +251,25 @@ func (s Structure) Field(i int) (Value, error) {
 	return s.fields[i], nil
 }
 
+// SetField sets field i of s in place. Copies of s made by
+// assignment have fields of their own, so they don't see the change.
+func (s Structure) SetField(i int, v Value) error {
+	if i < 0 || i >= len(s.fields) {
+		return errors.New("Index out of range")
+	}
+	s.fields[i] = v
+	return nil
+}
+
+// FieldAddr gives the address of field i of s, as the program's
+// FieldAddr instruction does.
+func (s Structure) FieldAddr(i int) (*Value, error) {
+	if i < 0 || i >= len(s.fields) {
+		return nil, errors.New("Index out of range")
+	}
+	return &s.fields[i], nil
+}
+
 func (s Structure) SetName(i int, name string) error {
 	if i < 0 || i > len(s.fields) {
 		return errors.New("Index out of range")
@@ -261,3 +290,125 @@ func (s Structure) FieldByName(name string) (Value, error) {
 func (s Structure) NumField() int {
 	return len(s.fields)
 }
+
+// CopyValDeep is like copyVal, but it also copies the elements of
+// structures, arrays, slices and maps rather than sharing them. It
+// doesn't follow pointers. The debugger uses it to take a snapshot of
+// a value which it can later compare against.
+func CopyValDeep(v Value) Value {
+	switch v := v.(type) {
+	case Structure:
+		a := Structure{
+			fields    : make([]Value, len(v.fields)),
+			fieldnames: v.fieldnames,
+		}
+		for i, e := range v.fields {
+			a.fields[i] = CopyValDeep(e)
+		}
+		return a
+	case array:
+		a := make(array, len(v))
+		for i, e := range v {
+			a[i] = CopyValDeep(e)
+		}
+		return a
+	case []Value:
+		if v == nil { return v }
+		a := make([]Value, len(v))
+		for i, e := range v {
+			a[i] = CopyValDeep(e)
+		}
+		return a
+	case tuple:
+		a := make(tuple, len(v))
+		for i, e := range v {
+			a[i] = CopyValDeep(e)
+		}
+		return a
+	case iface:
+		return iface{t: v.t, v: CopyValDeep(v.v)}
+	case map[Value]Value:
+		if v == nil { return v }
+		m := make(map[Value]Value, len(v))
+		for k, e := range v {
+			m[k] = CopyValDeep(e)
+		}
+		return m
+	case *hashmap:
+		if v == nil { return v }
+		m := &hashmap{
+			keyType: v.keyType,
+			table  : make(map[int]*entry, len(v.table)),
+			length : v.length,
+		}
+		for hash, e := range v.table {
+			var head, tail *entry
+			for ; e != nil; e = e.next {
+				n := &entry{key: e.key, Value: CopyValDeep(e.Value)}
+				if tail == nil { head = n } else { tail.next = n }
+				tail = n
+			}
+			m.table[hash] = head
+		}
+		return m
+	}
+	return v
+}
+
+// MapLookup returns the element of map m at key, and whether
+// there is one.
+func MapLookup(m Value, key Value) (Value, bool) {
+	switch m := m.(type) {
+	case map[Value]Value:
+		v, ok := m[key]
+		return v, ok
+	case *hashmap:
+		k, ok := key.(hashable)
+		if !ok { return nil, false }
+		v := m.lookup(k)
+		return v, v != nil
+	}
+	return nil, false
+}
+
+// Store sets *addr to v for the debugger stopped in frame fr. Like a
+// store made by the program, the write goes into the execution history
+// and WriteHook hears of it, with a nil instruction.
+func Store(fr *Frame, addr *Value, v Value) {
+	if Recording() { recordWrite(addr) }
+	*addr = copyVal(v)
+	if WriteHook != nil {
+		WriteHook(fr, nil)
+	}
+}
+
+// MapUpdate sets the element of map m at key to v for the debugger
+// stopped in frame fr, the way Store does. It returns false if m isn't
+// a map or key can't be a key of m.
+func MapUpdate(fr *Frame, m Value, key Value, v Value) bool {
+	switch m.(type) {
+	case map[Value]Value:
+	case *hashmap:
+		if _, ok := key.(hashable); !ok { return false }
+	default:
+		return false
+	}
+	if Recording() { recordMapWrite(m, key) }
+	switch m := m.(type) {
+	case map[Value]Value:
+		m[key] = v
+	case *hashmap:
+		m.insert(key.(hashable), v)
+	}
+	if WriteHook != nil {
+		WriteHook(fr, nil)
+	}
+	return true
+}
+
+// CopyVal copies v the way assignment in the interpreted program
+// does.
+func CopyVal(v Value) Value { return copyVal(v) }
+
+// Zero returns the zero value of type t.
+func Zero(t types.Type) Value { return zero(t) }
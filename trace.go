@@ -33,10 +33,16 @@ const (
 	STMT_IN_LIST
 	SWITCH_COND
 	TRACE_CALL
+	WATCHPOINT
+	RECOVER
+	GOROUTINE_START
+	GOROUTINE_EXIT
+	POSTMORTEM
+	DEADLOCK
 )
 
 const TRACE_EVENT_FIRST = OTHER
-const TRACE_EVENT_LAST  = TRACE_CALL
+const TRACE_EVENT_LAST  = DEADLOCK
 
 type TraceEventMask map[TraceEvent]bool
 
@@ -64,6 +70,13 @@ func init() {
 		STMT_IN_LIST    : "STATEMENT in list",
 		SWITCH_COND     : "SWITCH condition",
 		PROGRAM_TERMINATION : "Program Terminated",
+		WATCHPOINT      : "Watchpoint",
+		PANIC           : "panic",
+		RECOVER         : "recover",
+		GOROUTINE_START : "goroutine start",
+		GOROUTINE_EXIT  : "goroutine exit",
+		POSTMORTEM      : "post-mortem",
+		DEADLOCK        : "deadlock",
 	}
 }
 